@@ -9,20 +9,36 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // timezone ของสนามต้องโหลดได้แม้เครื่องไม่มีฐานข้อมูล timezone
 
 	"github.com/gin-gonic/gin"
 
 	"courtopia-reserve/backend/internal/config"
 	"courtopia-reserve/backend/internal/database"
 	"courtopia-reserve/backend/internal/handlers"
+	"courtopia-reserve/backend/internal/notify"
+	"courtopia-reserve/backend/internal/rbac"
+	"courtopia-reserve/backend/internal/realtime"
 	"courtopia-reserve/backend/internal/repository"
 )
-func startScheduler(bookingRepo *repository.BookingRepository, userRepo *repository.UserRepository) {
+
+func startScheduler(h *handlers.Handler) {
 	ticker := time.NewTicker(1 * time.Minute)
 	go func() {
 		for range ticker.C {
 			log.Println("Running email notification scheduler...")
-			handlers.SendMail(bookingRepo, userRepo)
+			h.SendReminders(context.Background())
+			h.ProcessNoShows(context.Background())
+			h.CompleteEndedBookings(context.Background())
+		}
+	}()
+
+	// ส่งอีเมลใน outbox และ webhook ถี่กว่ารอบ scheduler หลัก เพื่อให้ไปถึงปลายทางเร็ว
+	outboxTicker := time.NewTicker(10 * time.Second)
+	go func() {
+		for range outboxTicker.C {
+			h.DeliverOutbox(context.Background())
+			h.DeliverWebhooks(context.Background())
 		}
 	}()
 }
@@ -49,7 +65,47 @@ func main() {
 	userRepo := repository.NewUserRepository(db)
 	courtRepo := repository.NewCourtRepository(db)
 	bookingRepo := repository.NewBookingRepository(db)
-	startScheduler(bookingRepo, userRepo)
+	// ข้อมูลที่สร้างก่อนมีหลายสนามจะถูกย้ายเข้าสนามเริ่มต้น
+	venueRepo := repository.NewVenueRepository(db)
+	if err := venueRepo.EnsureDefault(context.Background(), cfg.Timezone); err != nil {
+		log.Fatalf("Error migrating venues: %v", err)
+	}
+	// เวลาการจองเดิมถูกเก็บเป็นเวลาท้องถิ่นแต่ติดป้าย UTC แปลงให้เป็นเวลาจริงตาม timezone ของสนาม
+	if err := venueRepo.MigrateLocalTimes(context.Background()); err != nil {
+		log.Fatalf("Error migrating booking times: %v", err)
+	}
+	if err := courtRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Error creating court indexes: %v", err)
+	}
+	if err := bookingRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Error creating booking indexes: %v", err)
+	}
+	// ผู้ใช้เดิมยังมี role แบบเก่า (user, club) เปลี่ยนเป็นชื่อ role ใหม่
+	for from, to := range rbac.LegacyRoles() {
+		if n, err := userRepo.RenameRole(context.Background(), from, to); err != nil {
+			log.Fatalf("Error migrating %s role: %v", from, err)
+		} else if n > 0 {
+			log.Printf("Renamed role %s to %s for %d users", from, to, n)
+		}
+	}
+	// ผู้ใช้ที่สมัครก่อนมีการยืนยันอีเมลถือว่ายืนยันแล้ว เพื่อไม่ให้ถูกจำกัดการจองทันที
+	if n, err := userRepo.GrandfatherEmailVerification(context.Background()); err != nil {
+		log.Fatalf("Error migrating email verification: %v", err)
+	} else if n > 0 {
+		log.Printf("Marked %d existing users as email verified", n)
+	}
+	if database.SupportsTransactions(context.Background(), client) {
+		bookingRepo.UseTransactions(true)
+	} else {
+		log.Println("MongoDB does not support transactions, booking changes and outbox messages are written without one")
+	}
+
+	// สร้างช่องทางส่งการแจ้งเตือน
+	notifier, err := notify.New(cfg)
+	if err != nil {
+		log.Fatalf("Error creating notifier: %v", err)
+	}
+	log.Printf("Sending notifications with %s driver", cfg.NotifyDriver)
 
 	// Set Gin mode based on environment
 	if cfg.Environment == "production" {
@@ -58,6 +114,9 @@ func main() {
 
 	// สร้าง Gin engine
 	r := gin.Default()
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	r.Static("/uploads", "./uploads")
 
@@ -83,9 +142,15 @@ func main() {
 	})
 
 	// สร้าง handler และลงทะเบียน routes
-	h := handlers.NewHandler(db, userRepo, courtRepo, bookingRepo, cfg.JWTSecret)
+	// broker ส่งการเปลี่ยนแปลงของคอร์ทให้หน้าจองแบบ real-time ภายใน process นี้
+	broker := realtime.NewBroker()
+	h := handlers.NewHandler(db, userRepo, courtRepo, bookingRepo, notifier, broker, cfg)
+	if err := h.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Error creating indexes: %v", err)
+	}
 	h.RegisterRoutes(r)
-	h = handlers.NewHandler(db, userRepo, courtRepo, bookingRepo, cfg.JWTSecret)
+	startScheduler(h)
+	h = handlers.NewHandler(db, userRepo, courtRepo, bookingRepo, notifier, broker, cfg)
 	r.POST("/trigger-email-notifications", h.TriggerEmailNotifications)
 
 	// เริ่มต้น server
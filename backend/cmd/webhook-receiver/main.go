@@ -0,0 +1,61 @@
+// Command webhook-receiver is a local endpoint for trying out webhooks.
+// It verifies each request's signature and prints the event it received.
+//
+//	go run ./cmd/webhook-receiver -addr :9000 -secret <webhook secret>
+//
+// Register http://localhost:9000/ as a webhook, then book or cancel a court.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"courtopia-reserve/backend/internal/webhook"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "address to listen on")
+	secret := flag.String("secret", "", "shared secret registered with the webhook")
+	fail := flag.Bool("fail", false, "answer 500 to every request to exercise retries")
+	flag.Parse()
+
+	if *secret == "" {
+		log.Fatal("-secret is required")
+	}
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "cannot read body", http.StatusBadRequest)
+			return
+		}
+
+		timestamp := r.Header.Get(webhook.HeaderTimestamp)
+		signature := r.Header.Get(webhook.HeaderSignature)
+		if !webhook.Verify(*secret, timestamp, signature, body, time.Now()) {
+			log.Printf("rejected %s: bad signature", r.Header.Get(webhook.HeaderDelivery))
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, body, "", "  "); err != nil {
+			pretty.Write(body)
+		}
+		log.Printf("%s (delivery %s)\n%s", r.Header.Get(webhook.HeaderEvent), r.Header.Get(webhook.HeaderDelivery), pretty.String())
+
+		if *fail {
+			http.Error(w, "failing on purpose", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Printf("Listening for webhooks on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
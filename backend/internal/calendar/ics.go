@@ -0,0 +1,126 @@
+// Package calendar renders bookings as iCalendar (RFC 5545) data.
+package calendar
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ProductID identifies this application in generated calendars
+const ProductID = "-//Courtminton//Court Booking//EN"
+
+// ContentType is the MIME type of iCalendar data
+const ContentType = "text/calendar; charset=UTF-8"
+
+// Event statuses
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+// Event is a single VEVENT
+type Event struct {
+	UID          string // must stay the same for the lifetime of the booking
+	Summary      string
+	Description  string
+	Location     string
+	Start        time.Time
+	End          time.Time
+	Status       string
+	Sequence     int // bumped every time the event changes
+	Created      time.Time
+	LastModified time.Time
+}
+
+// Calendar is a VCALENDAR containing zero or more events
+type Calendar struct {
+	Name   string // shown by clients that support X-WR-CALNAME
+	Method string // e.g. PUBLISH; optional
+	Events []Event
+}
+
+// Bytes renders the calendar as iCalendar text with CRLF line endings and folded lines
+func (c Calendar) Bytes() []byte {
+	var buf bytes.Buffer
+	w := &writer{buf: &buf}
+
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:" + ProductID)
+	w.line("CALSCALE:GREGORIAN")
+	if c.Method != "" {
+		w.line("METHOD:" + c.Method)
+	}
+	if c.Name != "" {
+		w.line("X-WR-CALNAME:" + escapeText(c.Name))
+	}
+
+	stamp := time.Now()
+	for _, e := range c.Events {
+		w.line("BEGIN:VEVENT")
+		w.line("UID:" + e.UID)
+		w.line("DTSTAMP:" + formatTime(stamp))
+		w.line("DTSTART:" + formatTime(e.Start))
+		w.line("DTEND:" + formatTime(e.End))
+		w.line("SUMMARY:" + escapeText(e.Summary))
+		if e.Description != "" {
+			w.line("DESCRIPTION:" + escapeText(e.Description))
+		}
+		if e.Location != "" {
+			w.line("LOCATION:" + escapeText(e.Location))
+		}
+		if e.Status != "" {
+			w.line("STATUS:" + e.Status)
+		}
+		w.line(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+		if !e.Created.IsZero() {
+			w.line("CREATED:" + formatTime(e.Created))
+		}
+		if !e.LastModified.IsZero() {
+			w.line("LAST-MODIFIED:" + formatTime(e.LastModified))
+		}
+		w.line("END:VEVENT")
+	}
+
+	w.line("END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// formatTime formats t as a UTC DATE-TIME value
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeText escapes a TEXT value as described in RFC 5545 section 3.3.11
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, ";", "\\;")
+	s = strings.ReplaceAll(s, ",", "\\,")
+	s = strings.ReplaceAll(s, "\r\n", "\\n")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return s
+}
+
+// writer writes content lines folded at 75 octets without splitting UTF-8 characters
+type writer struct {
+	buf *bytes.Buffer
+}
+
+func (w *writer) line(s string) {
+	const limit = 75
+	width := 0
+	for len(s) > 0 {
+		_, size := utf8.DecodeRuneInString(s)
+		if width+size > limit {
+			w.buf.WriteString("\r\n ")
+			width = 1
+		}
+		w.buf.WriteString(s[:size])
+		width += size
+		s = s[size:]
+	}
+	w.buf.WriteString("\r\n")
+}
@@ -0,0 +1,53 @@
+// Package clock provides the current time and venue timezones.
+// Handlers read the time through a Clock so tests can pin "now".
+package clock
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultTimezone is the timezone used when none is configured
+const DefaultTimezone = "Asia/Bangkok"
+
+// Clock returns the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the real wall clock
+type System struct{}
+
+// Now returns time.Now()
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// Fixed is a clock that always returns the same instant
+type Fixed time.Time
+
+// Now returns the fixed instant
+func (f Fixed) Now() time.Time {
+	return time.Time(f)
+}
+
+var locations sync.Map // ชื่อ timezone -> *time.Location
+
+// LoadLocation loads an IANA timezone such as Asia/Bangkok and caches it.
+// The server's own zone ("" or "Local") is rejected so results never depend on where the server runs.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, errors.New("timezone must be an IANA name such as " + DefaultTimezone)
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+
+	"courtopia-reserve/backend/internal/clock"
 )
 
 // Config holds all configuration for the application
@@ -14,6 +18,26 @@ type Config struct {
 	Port        int
 	JWTSecret   string
 	Environment string
+	AppURL      string // URL ของหน้าเว็บ ใช้สร้างลิงก์ในอีเมล
+	Timezone    string // timezone ของสนามเริ่มต้นและสนามที่ไม่ได้กำหนด timezone
+
+	// proxy ที่เชื่อ X-Forwarded-For ได้ ถ้าไม่กำหนดจะใช้ IP ที่เชื่อมต่อเข้ามาตรงๆ
+	// (การจำกัดการ login ผิดตาม IP จะถูกหลบได้ถ้าเชื่อ header จากทุกคน)
+	TrustedProxies []string
+
+	// อายุของ token: access token สั้นๆ และ refresh token ที่หมุนเปลี่ยนทุกครั้งที่ใช้
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// การส่งอีเมลแจ้งเตือน
+	NotifyDriver  string // smtp, file, memory
+	NotifyFileDir string // โฟลเดอร์ที่ driver file เขียนอีเมลลงไป
+	SMTPHost      string
+	SMTPPort      int
+	SMTPUsername  string
+	SMTPPassword  string
+	SMTPFrom      string
+	SMTPTLSMode   string // none, starttls, tls
 }
 
 // LoadConfig loads configuration from .env file and environment variables
@@ -32,6 +56,16 @@ func LoadConfig() (*Config, error) {
 		Port:        8000,
 		JWTSecret:   "your-secret-key",
 		Environment: "development",
+		AppURL:      "http://localhost:8080",
+		Timezone:    clock.DefaultTimezone,
+
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 30 * 24 * time.Hour,
+
+		NotifyFileDir: "./mail",
+		SMTPPort:      587,
+		SMTPFrom:      "no-reply@courtminton.local",
+		SMTPTLSMode:   "starttls",
 	}
 
 	// เพิ่มการแสดงผลว่ามีการอ่านค่า environment variable หรือไม่
@@ -57,6 +91,73 @@ func LoadConfig() (*Config, error) {
 	if env := os.Getenv("ENVIRONMENT"); env != "" {
 		cfg.Environment = env
 	}
+
+	if appURL := os.Getenv("APP_URL"); appURL != "" {
+		cfg.AppURL = strings.TrimRight(appURL, "/")
+	}
+
+	if tz := os.Getenv("TIMEZONE"); tz != "" {
+		cfg.Timezone = tz
+	}
+	if _, err := clock.LoadLocation(cfg.Timezone); err != nil {
+		return nil, fmt.Errorf("invalid TIMEZONE %q: %w", cfg.Timezone, err)
+	}
+
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		for _, proxy := range strings.Split(proxies, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				cfg.TrustedProxies = append(cfg.TrustedProxies, proxy)
+			}
+		}
+	}
+
+	if minutesStr := os.Getenv("ACCESS_TOKEN_MINUTES"); minutesStr != "" {
+		minutes, err := strconv.Atoi(minutesStr)
+		if err == nil && minutes > 0 {
+			cfg.AccessTokenTTL = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if daysStr := os.Getenv("REFRESH_TOKEN_DAYS"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err == nil && days > 0 {
+			cfg.RefreshTokenTTL = time.Duration(days) * 24 * time.Hour
+		}
+	}
+
+	cfg.SMTPHost = os.Getenv("SMTP_HOST")
+	cfg.SMTPUsername = os.Getenv("SMTP_USERNAME")
+	cfg.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+
+	if portStr := os.Getenv("SMTP_PORT"); portStr != "" {
+		port, err := strconv.Atoi(portStr)
+		if err == nil {
+			cfg.SMTPPort = port
+		}
+	}
+
+	if from := os.Getenv("SMTP_FROM"); from != "" {
+		cfg.SMTPFrom = from
+	}
+
+	if tlsMode := os.Getenv("SMTP_TLS_MODE"); tlsMode != "" {
+		cfg.SMTPTLSMode = tlsMode
+	}
+
+	if dir := os.Getenv("NOTIFY_FILE_DIR"); dir != "" {
+		cfg.NotifyFileDir = dir
+	}
+
+	// ถ้าไม่ได้กำหนด driver ให้ใช้ SMTP เมื่อมี SMTP_HOST ไม่เช่นนั้นเขียนอีเมลลงไฟล์
+	cfg.NotifyDriver = os.Getenv("NOTIFY_DRIVER")
+	if cfg.NotifyDriver == "" {
+		if cfg.SMTPHost != "" {
+			cfg.NotifyDriver = "smtp"
+		} else {
+			cfg.NotifyDriver = "file"
+		}
+	}
+
 	fmt.Printf("db: %s\n", cfg.MongoURI)
 	return cfg, nil
 }
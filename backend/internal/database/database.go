@@ -5,6 +5,7 @@ import (
 	"log"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -32,3 +33,18 @@ func ConnectDB(uri string) (*mongo.Client, error) {
 
 	return client, nil
 }
+
+// SupportsTransactions reports whether the server accepts multi-document transactions,
+// which requires a replica set or a sharded cluster
+func SupportsTransactions(ctx context.Context, client *mongo.Client) bool {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		log.Printf("Failed to check MongoDB topology: %v", err)
+		return false
+	}
+	return hello.SetName != "" || hello.Msg == "isdbgrid"
+}
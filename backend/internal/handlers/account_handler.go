@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/notify"
+	"courtopia-reserve/backend/internal/repository"
+	"courtopia-reserve/backend/pkg/utils"
+)
+
+const (
+	// verifyEmailTTL is how long an email verification link works
+	verifyEmailTTL = 48 * time.Hour
+	// resetPasswordTTL is how long a password reset link works
+	resetPasswordTTL = time.Hour
+
+	// actionEmailWindow is the period the limits below are counted over
+	actionEmailWindow = time.Hour
+	// actionEmailLimit is how many emails of one purpose an account can be sent per window
+	actionEmailLimit = 3
+	// actionEmailIPLimit is how many password reset emails one IP address can request per window
+	actionEmailIPLimit = 10
+)
+
+// sendActionEmail ออก token ใช้ครั้งเดียวให้ผู้ใช้ แล้วส่งลิงก์ที่มี token ทางอีเมลผ่าน outbox
+func (h *Handler) sendActionEmail(ctx context.Context, user *models.User, ip string, purpose string, template string, path string, ttl time.Duration) error {
+	record := &models.ActionToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		IPAddress: ip,
+		ExpiresAt: h.clock.Now().Add(ttl),
+	}
+	token := utils.SignActionToken(h.jwtSecret, purpose, record.ID.Hex(), record.ExpiresAt)
+	record.TokenHash = utils.HashToken(token)
+
+	if err := h.actionTokenRepo.Create(ctx, record); err != nil {
+		return err
+	}
+
+	link := h.cfg.AppURL + path + "?token=" + url.QueryEscape(token)
+	msg := repository.NewAccountOutboxMessage(template, user, map[string]string{
+		"link":          link,
+		"email":         user.Email,
+		"expires_hours": strconv.Itoa(int(ttl / time.Hour)),
+	})
+	return h.outboxRepo.Create(ctx, msg)
+}
+
+// sendVerificationEmail ส่งลิงก์ยืนยันอีเมลไปยังอีเมลปัจจุบันของผู้ใช้
+func (h *Handler) sendVerificationEmail(ctx context.Context, user *models.User, ip string) error {
+	return h.sendActionEmail(ctx, user, ip, repository.PurposeVerifyEmail, notify.TemplateVerifyEmail, "/verify-email", verifyEmailTTL)
+}
+
+// actionEmailAllowed ตรวจว่ายังส่งอีเมลจุดประสงค์นี้ให้บัญชีได้อีกโดยไม่เกินจำนวนที่กำหนดในช่วงเวลา
+// ถ้าระบุ ipLimit จะจำกัดจำนวนที่ขอจาก IP เดียวกันด้วย
+func (h *Handler) actionEmailAllowed(ctx context.Context, user *models.User, ip string, purpose string, ipLimit int) (bool, error) {
+	since := h.clock.Now().Add(-actionEmailWindow)
+
+	count, err := h.actionTokenRepo.CountIssuedSince(ctx, user.ID, purpose, since)
+	if err != nil || count >= actionEmailLimit {
+		return false, err
+	}
+
+	if ipLimit > 0 {
+		count, err := h.actionTokenRepo.CountIssuedToIPSince(ctx, ip, purpose, since)
+		if err != nil || count >= int64(ipLimit) {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// consumeActionToken ตรวจลายเซ็นและอายุของ token แล้วทำเครื่องหมายว่าใช้แล้ว
+func (h *Handler) consumeActionToken(ctx context.Context, purpose string, token string) (*models.ActionToken, error) {
+	idHex, ok := utils.ParseActionToken(h.jwtSecret, purpose, token, h.clock.Now())
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	id, err := primitive.ObjectIDFromHex(idHex)
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	return h.actionTokenRepo.Consume(ctx, id, purpose, utils.HashToken(token))
+}
+
+// VerifyEmail ยืนยันอีเมลด้วย token จากลิงก์ในอีเมล
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	record, err := h.consumeActionToken(c.Request.Context(), repository.PurposeVerifyEmail, req.Token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	verified, err := h.userRepo.MarkEmailVerified(c.Request.Context(), record.UserID, record.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	if !verified {
+		// ผู้ใช้เปลี่ยนอีเมลไปแล้วหลังจากได้รับลิงก์
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerificationEmail ส่งลิงก์ยืนยันอีเมลใหม่ ลิงก์เดิมจะใช้ไม่ได้อีก
+func (h *Handler) ResendVerificationEmail(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	user, err := h.userRepo.FindByStudentID(c.Request.Context(), claims.StudentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Add an email address to your profile first"})
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is already verified"})
+		return
+	}
+
+	allowed, err := h.actionEmailAllowed(c.Request.Context(), user, c.ClientIP(), repository.PurposeVerifyEmail, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many verification emails requested, please try again later"})
+		return
+	}
+
+	if err := h.sendVerificationEmail(c.Request.Context(), user, c.ClientIP()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// ForgotPassword ส่งลิงก์ตั้งรหัสผ่านใหม่ไปยังอีเมลของบัญชี
+// ตอบเหมือนกันเสมอ ไม่ว่าจะพบบัญชีหรือไม่ เพื่อไม่ให้ใช้ตรวจว่ามีบัญชีใดอยู่ในระบบ
+// คำขอที่เกินจำนวนต่อบัญชีหรือต่อ IP จะไม่ส่งอีเมลแต่ตอบเหมือนเดิม ด้วยเหตุผลเดียวกัน
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.StudentID == "" && req.Email == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "studentId or email is required"})
+		return
+	}
+
+	var user *models.User
+	var err error
+	if req.StudentID != "" {
+		user, err = h.userRepo.FindByStudentID(c.Request.Context(), req.StudentID)
+	} else {
+		user, err = h.userRepo.FindByEmail(c.Request.Context(), req.Email)
+	}
+
+	switch {
+	case err == nil && user.Email != "":
+		allowed, err := h.actionEmailAllowed(c.Request.Context(), user, c.ClientIP(), repository.PurposeResetPassword, actionEmailIPLimit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if !allowed {
+			log.Printf("Password reset email for %s from %s not sent: too many requests", user.StudentID, c.ClientIP())
+			break
+		}
+		if err := h.sendActionEmail(c.Request.Context(), user, c.ClientIP(), repository.PurposeResetPassword, notify.TemplatePasswordReset, "/reset-password", resetPasswordTTL); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send password reset email"})
+			return
+		}
+	case err != nil && !errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the account has an email address, a password reset link has been sent"})
+}
+
+// ResetPassword ตั้งรหัสผ่านใหม่ด้วย token จากลิงก์ในอีเมล แล้ว logout ทุก session
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	record, err := h.consumeActionToken(c.Request.Context(), repository.PurposeResetPassword, req.Token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset link"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	if err := h.userRepo.UpdatePassword(c.Request.Context(), record.UserID, hashedPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	// รหัสผ่านเดิมอาจรั่ว จึงให้ทุกอุปกรณ์ login ใหม่
+	if _, err := h.sessionRepo.RevokeAllForUser(c.Request.Context(), record.UserID, "password_reset"); err != nil {
+		log.Printf("Error revoking sessions after password reset: %v", err)
+	}
+
+	// เปิดลิงก์จากอีเมลได้ แปลว่าเป็นเจ้าของอีเมลนั้น
+	if _, err := h.userRepo.MarkEmailVerified(c.Request.Context(), record.UserID, record.Email); err != nil {
+		log.Printf("Error marking email verified after password reset: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
@@ -1,14 +1,17 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/notify"
+	"courtopia-reserve/backend/internal/rbac"
+	"courtopia-reserve/backend/internal/repository"
 	"courtopia-reserve/backend/pkg/utils"
 )
 
@@ -21,6 +24,12 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
+	// ภาษาของอีเมลต้องเป็นภาษาที่มี template
+	if req.Language != "" && !notify.SupportedLanguage(req.Language) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Language must be th or en"})
+		return
+	}
+
 	// ตรวจสอบว่ามีผู้ใช้นี้ในระบบแล้วหรือไม่
 	_, err := h.userRepo.FindByStudentID(c.Request.Context(), req.StudentID)
 	if err == nil {
@@ -45,9 +54,10 @@ func (h *Handler) Register(c *gin.Context) {
 		Password:  hashedPassword,
 		Name:      req.Name,
 		Email:     req.Email,
-		Role:      "user", // กำหนดเป็น user ปกติ
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Language:  req.Language,
+		Role:      rbac.RoleStudent, // ผู้ใช้ที่สมัครเองเป็นนักศึกษาเสมอ
+		CreatedAt: h.clock.Now(),
+		UpdatedAt: h.clock.Now(),
 	}
 
 	// บันทึกลงฐานข้อมูล
@@ -56,11 +66,18 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
+	// ส่งลิงก์ยืนยันอีเมล ถ้าส่งไม่สำเร็จผู้ใช้ขอส่งใหม่ได้ภายหลัง
+	if user.Email != "" {
+		if err := h.sendVerificationEmail(c.Request.Context(), user, c.ClientIP()); err != nil {
+			log.Printf("Error sending verification email to %s: %v", user.StudentID, err)
+		}
+	}
+
 	// ส่ง response กลับไป
 	c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully"})
 }
 
-// Login จัดการการเข้าสู่ระบบและสร้าง JWT token
+// Login จัดการการเข้าสู่ระบบ สร้าง session และออก JWT token
 func (h *Handler) Login(c *gin.Context) {
 	// อ่านข้อมูลจาก request body
 	var req models.LoginRequest
@@ -69,31 +86,46 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
+	// บัญชีหรือ IP ที่ login ผิดบ่อยต้องรอก่อนลองใหม่
+	retryAt, reason, err := h.checkLoginThrottle(c.Request.Context(), req.StudentID, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if reason != "" {
+		h.recordLoginAttempt(c, req.StudentID, nil, reason)
+		h.rejectThrottledLogin(c, retryAt)
+		return
+	}
+
 	// ค้นหาผู้ใช้จากรหัสนักศึกษา
 	user, err := h.userRepo.FindByStudentID(c.Request.Context(), req.StudentID)
 	if err != nil {
+		h.recordLoginFailure(c, req.StudentID, nil, loginUnknownUser)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "รหัสนักศึกษาหรือรหัสผ่านไม่ถูกต้อง"})
 		return
 	}
 
 	// ตรวจสอบรหัสผ่าน
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		h.recordLoginFailure(c, req.StudentID, user, loginInvalidPassword)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "รหัสนักศึกษาหรือรหัสผ่านไม่ถูกต้อง"})
 		return
 	}
 
-	// สร้าง JWT token
-	token, err := utils.GenerateToken(user, h.jwtSecret, 24)
+	// login สำเร็จ ล้างจำนวนครั้งที่ผิดของบัญชี (ของ IP ไม่ล้าง เพราะผู้โจมตีอาจมีบัญชีของตัวเอง)
+	if err := h.loginRepo.ClearThrottle(c.Request.Context(), repository.LoginThrottleAccount, user.StudentID); err != nil {
+		log.Printf("Error clearing login throttle for %s: %v", user.StudentID, err)
+	}
+	h.recordLoginAttempt(c, user.StudentID, user, loginSuccess)
+
+	// สร้าง session พร้อม access token และ refresh token
+	response, err := h.startSession(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
 	// ส่ง response พร้อม token กลับไป
-	c.JSON(http.StatusOK, models.LoginResponse{
-		Token:     token,
-		StudentID: user.StudentID,
-		Name:      user.Name,
-		Role:      user.Role,
-	})
+	c.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/pkg/utils"
+)
+
+// GetBlackouts ดึงช่วงปิดปรับปรุงคอร์ทที่ยังไม่สิ้นสุดของสนามที่ดูแล (สำหรับผู้ดูแลสนาม)
+func (h *Handler) GetBlackouts(c *gin.Context) {
+	venueIDs, ok := adminVenueScope(c)
+	if !ok {
+		return
+	}
+
+	blackouts, err := h.blackoutRepo.FindUpcoming(c.Request.Context(), venueIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blackouts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, blackouts)
+}
+
+// CreateBlackout สร้างช่วงปิดปรับปรุงคอร์ทของสนาม และคืนรายการจองที่ทับช่วงเวลานั้น (สำหรับผู้ดูแลสนาม)
+func (h *Handler) CreateBlackout(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	var req models.BlackoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	venue, ok := h.findManagedVenue(c, req.VenueID)
+	if !ok {
+		return
+	}
+
+	if req.EndDate == "" {
+		req.EndDate = req.StartDate
+	}
+
+	// วันเวลาที่ระบุเป็นเวลาท้องถิ่นของสนาม
+	loc := h.venueLocation(venue)
+	startTime, err := time.ParseInLocation("2006-01-02 15:04", req.StartDate+" "+req.StartTime, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date or time, use YYYY-MM-DD and HH:MM"})
+		return
+	}
+
+	endTime, err := time.ParseInLocation("2006-01-02 15:04", req.EndDate+" "+req.EndTime, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date or time, use YYYY-MM-DD and HH:MM"})
+		return
+	}
+
+	if !endTime.After(startTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End time must be after start time"})
+		return
+	}
+
+	// ตรวจสอบว่าคอร์ทที่ระบุมีอยู่จริง
+	for _, courtNumber := range req.CourtNumbers {
+		if _, err := h.courtRepo.FindByCourtNumber(c.Request.Context(), venue.ID, courtNumber); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Court not found", "courtNumber": courtNumber})
+			return
+		}
+	}
+
+	blackout := &models.Blackout{
+		ID:           primitive.NewObjectID(),
+		VenueID:      venue.ID,
+		CourtNumbers: req.CourtNumbers,
+		StartTime:    startTime,
+		EndTime:      endTime,
+		Reason:       req.Reason,
+		CreatedBy:    claims.StudentID,
+	}
+
+	if err := h.blackoutRepo.Create(c.Request.Context(), blackout); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create blackout"})
+		return
+	}
+
+	// รายการจองที่ทับช่วงปิดปรับปรุง เพื่อให้ admin ยกเลิกและแจ้งผู้เล่น
+	affected, err := h.bookingRepo.FindActiveInWindow(c.Request.Context(), venue.ID, req.CourtNumbers, startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch affected bookings"})
+		return
+	}
+
+	rows, err := h.adminBookingRows(c.Request.Context(), affected)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch affected bookings"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.BlackoutResponse{
+		Blackout:         blackout,
+		AffectedBookings: rows,
+	})
+}
+
+// DeleteBlackout ลบช่วงปิดปรับปรุงคอร์ท (สำหรับผู้ดูแลสนาม)
+func (h *Handler) DeleteBlackout(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid blackout ID"})
+		return
+	}
+
+	blackout, err := h.blackoutRepo.FindByID(c.Request.Context(), id)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Blackout not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete blackout"})
+		return
+	}
+	if !requireVenueAccess(c, blackout.VenueID) {
+		return
+	}
+
+	err = h.blackoutRepo.Delete(c.Request.Context(), id)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Blackout not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete blackout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Blackout deleted successfully"})
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"courtopia-reserve/backend/internal/clock"
+	"courtopia-reserve/backend/internal/rbac"
+)
+
+// TestCreateBookingConcurrent ส่งคำขอจองคอร์ทและเวลาเดียวกันพร้อมกันหลายคำขอ
+// ต้องมีคำขอเดียวที่ได้ 201 ที่เหลือได้ 409 และมีการจองกับ lock ของคอร์ทและวันนั้นอย่างละหนึ่งเอกสาร
+func TestCreateBookingConcurrent(t *testing.T) {
+	h, db := newTestHandler(t)
+	ctx := context.Background()
+
+	venue, court := newTestCourt(t, h, 3)
+	loc, err := clock.LoadLocation(venue.Timezone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.UseClock(clock.Fixed(time.Date(2030, 1, 7, 9, 0, 0, 0, loc)))
+
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	const n = 20
+	tokens := make([]string, n)
+	for i := range tokens {
+		tokens[i] = newTestUser(t, h, fmt.Sprintf("6500%04d", i), rbac.RoleStudent)
+	}
+
+	body := fmt.Sprintf(`{"venueId":%q,"courtNumber":3,"bookingDate":"2030-01-07","startTime":"18:00","endTime":"19:00"}`, venue.ID.Hex())
+
+	codes := make([]int, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			req := httptest.NewRequest(http.MethodPost, "/api/bookings", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tokens[i])
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			codes[i] = rec.Code
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	created, conflicts := 0, 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+			conflicts++
+		}
+	}
+	if created != 1 || conflicts != n-1 {
+		t.Fatalf("got %d created and %d conflicts, want 1 and %d (codes %v)", created, conflicts, n-1, codes)
+	}
+
+	active, err := db.Collection("bookings").CountDocuments(ctx, bson.M{"court_id": court.ID, "status": "active"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active != 1 {
+		t.Errorf("got %d active bookings, want 1", active)
+	}
+
+	locks, err := db.Collection("slot_locks").CountDocuments(ctx, bson.M{"court_id": court.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if locks != 1 {
+		t.Errorf("got %d slot_locks documents, want 1", locks)
+	}
+}
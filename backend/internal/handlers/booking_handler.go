@@ -2,17 +2,21 @@ package handlers
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"io"
 	"log"
 	"net/http"
-	"net/smtp"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/notify"
+	"courtopia-reserve/backend/internal/rbac"
 	"courtopia-reserve/backend/internal/repository"
+	"courtopia-reserve/backend/internal/webhook"
 	"courtopia-reserve/backend/pkg/utils"
 )
 
@@ -40,91 +44,24 @@ func (h *Handler) CreateBooking(c *gin.Context) {
 		return
 	}
 
-	// แปลงวันที่และเวลาให้อยู่ในรูปแบบที่ถูกต้อง
-	bookingDate, err := time.Parse("2006-01-02", req.BookingDate)
+	// ตรวจสอบสนาม วันเวลา และคอร์ทที่ต้องการจอง
+	venue, err := h.resolveVenue(c.Request.Context(), req.VenueID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format, use YYYY-MM-DD"})
+		respondSlotError(c, err)
 		return
 	}
-
-	// รูปแบบเวลา
-	layout := "15:04"
-	startTimeParsed, err := time.Parse(layout, req.StartTime)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start time format, use HH:MM"})
-		return
-	}
-
-	endTimeParsed, err := time.Parse(layout, req.EndTime)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end time format, use HH:MM"})
-		return
-	}
-
-	// สร้าง datetime objects สำหรับช่วงเวลาที่ต้องการจอง
-	startTime := time.Date(
-		bookingDate.Year(),
-		bookingDate.Month(),
-		bookingDate.Day(),
-		startTimeParsed.Hour(),
-		startTimeParsed.Minute(),
-		0,
-		0,
-		bookingDate.Location(),
-	)
-
-	endTime := time.Date(
-		bookingDate.Year(),
-		bookingDate.Month(),
-		bookingDate.Day(),
-		endTimeParsed.Hour(),
-		endTimeParsed.Minute(),
-		0,
-		0,
-		bookingDate.Location(),
-	)
-
-	// ตรวจสอบว่าเวลาถูกต้องหรือไม่
-	if startTime.Before(time.Now()) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Booking time must be in the future"})
-		return
-	}
-
-	if endTime.Before(startTime) || endTime.Equal(startTime) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "End time must be after start time"})
-		return
-	}
-
-	// กำหนดให้จองได้ไม่เกิน 2 ชั่วโมง
-	maxDuration := 2 * time.Hour
-	if endTime.Sub(startTime) > maxDuration {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Booking duration cannot exceed 2 hours"})
-		return
-	}
-
-	// ตรวจสอบว่าคอร์ทมีอยู่จริงหรือไม่
-	court, err := h.courtRepo.FindByCourtNumber(c.Request.Context(), req.CourtNumber)
+	slot, err := h.validateBookingSlot(c.Request.Context(), venue, req.CourtNumber, req.BookingDate, req.StartTime, req.EndTime)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Court not found"})
+		respondSlotError(c, err)
 		return
 	}
 
-	// ตรวจสอบว่าคอร์ทใช้งานได้หรือไม่
-	if !court.IsActive {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Court is not available for booking"})
-		return
-	}
-
-	// ตรวจสอบว่าคอร์ทว่างในช่วงเวลาที่ต้องการหรือไม่
-	isAvailable, err := h.bookingRepo.IsCourtAvailable(c.Request.Context(), req.CourtNumber, bookingDate, startTime, endTime)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check court availability"})
-		return
-	}
-
-	if !isAvailable {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Court is not available for the selected time"})
-		return
+	// ตรวจสอบโควตาการจองของผู้ใช้ (ผู้ที่มีสิทธิ์ bypass ของสนามนี้ไม่ถูกจำกัด)
+	if !rbac.Can(userClaims.Role, rbac.BookingsBypassPolicy) || !canAccessVenue(userClaims, venue.ID) {
+		if err := h.checkBookingPolicy(c.Request.Context(), userClaims.StudentID, req.CourtNumber, slot, primitive.NilObjectID); err != nil {
+			respondSlotError(c, err)
+			return
+		}
 	}
 
 	// สร้างข้อมูลการจอง
@@ -132,38 +69,59 @@ func (h *Handler) CreateBooking(c *gin.Context) {
 		ID:               primitive.NewObjectID(),
 		UserID:           userID,
 		StudentID:        userClaims.StudentID,
-		CourtID:          court.ID,
+		VenueID:          venue.ID,
+		CourtID:          slot.Court.ID,
 		CourtNumber:      req.CourtNumber,
-		BookingDate:      bookingDate,
-		StartTime:        startTime,
-		EndTime:          endTime,
+		BookingDate:      slot.BookingDate,
+		StartTime:        slot.StartTime,
+		EndTime:          slot.EndTime,
 		Status:           "active",
 		NotificationSent: false,
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
+		CreatedAt:        h.clock.Now(),
+		UpdatedAt:        h.clock.Now(),
 		UserEmail:        userClaims.Email,
 	}
 
-
-	// บันทึกการจองลงฐานข้อมูล
-	if err := h.bookingRepo.Create(c.Request.Context(), booking); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create booking"})
+	// ตรวจสอบว่าคอร์ทว่างและบันทึกการจองพร้อมอีเมลยืนยันใน outbox ในขั้นตอนเดียว เพื่อกันการจองซ้อนกัน
+	confirmation := repository.NewOutboxMessage(notify.TemplateBookingConfirmation, booking)
+	if err := h.bookingRepo.CreateIfAvailable(c.Request.Context(), booking, confirmation); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrSlotUnavailable):
+			c.JSON(http.StatusConflict, gin.H{"error": "Court is not available for the selected time"})
+		case errors.Is(err, repository.ErrCourtUnavailable):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Court is not available for booking"})
+		case errors.Is(err, repository.ErrSlotLockTimeout):
+			c.JSON(http.StatusConflict, gin.H{"error": "Court is being booked by someone else, please try again"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create booking"})
+		}
 		return
 	}
 
-	// สร้างข้อมูล response
+	h.emitBookingEvent(c.Request.Context(), webhook.EventBookingCreated, booking)
+
+	// ส่งข้อมูลกลับ
+	c.JSON(http.StatusCreated, toBookingResponse(booking, h.venueLocation(venue)))
+}
+
+// toBookingResponse แปลงการจองเป็นรูปแบบที่ส่งกลับให้ client โดยแสดงวันและเวลาตาม timezone ของสนาม
+func toBookingResponse(booking *models.Booking, loc *time.Location) models.BookingResponse {
 	response := models.BookingResponse{
 		ID:          booking.ID.Hex(),
+		VenueID:     booking.VenueID.Hex(),
 		CourtNumber: booking.CourtNumber,
-		BookingDate: req.BookingDate,
-		StartTime:   req.StartTime,
-		EndTime:     req.EndTime,
+		BookingDate: booking.BookingDate.In(loc).Format("2006-01-02"),
+		StartTime:   booking.StartTime.In(loc).Format("15:04"),
+		EndTime:     booking.EndTime.In(loc).Format("15:04"),
 		Status:      booking.Status,
 		CreatedAt:   booking.CreatedAt,
 	}
-
-	// ส่งข้อมูลกลับ
-	c.JSON(http.StatusCreated, response)
+	if booking.SeriesID != nil {
+		response.SeriesID = booking.SeriesID.Hex()
+	}
+	response.Changes = booking.Changes
+	response.Cancellation = booking.Cancellation
+	return response
 }
 
 // GetUserBookings ดึงข้อมูลการจองของผู้ใช้
@@ -177,10 +135,8 @@ func (h *Handler) GetUserBookings(c *gin.Context) {
 	userClaims := claims.(*utils.Claims)
 
 	// อัปเดตสถานะการจองที่สิ้นสุดแล้วให้เป็น completed
-	if err := h.bookingRepo.UpdateCompletedBookings(c.Request.Context()); err != nil {
-		log.Printf("Error updating completed bookings: %v", err)
-		// ไม่ return error เพราะเราไม่ต้องการให้มันส่งผลกระทบต่อการดึงข้อมูล
-	}
+	// ความผิดพลาดถูก log ไว้ ไม่ส่งผลกระทบต่อการดึงข้อมูล
+	h.CompleteEndedBookings(c.Request.Context())
 
 	// เพิ่ม logging เพื่อตรวจสอบค่า studentID
 	log.Printf("Fetching bookings for student ID: %s", userClaims.StudentID)
@@ -193,18 +149,16 @@ func (h *Handler) GetUserBookings(c *gin.Context) {
 		return
 	}
 
+	zones, err := h.loadVenueZones(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookings"})
+		return
+	}
+
 	// แปลงข้อมูลให้อยู่ในรูปแบบที่ต้องการส่งกลับ
 	var response []models.BookingResponse
 	for _, booking := range bookings {
-		response = append(response, models.BookingResponse{
-			ID:          booking.ID.Hex(),
-			CourtNumber: booking.CourtNumber,
-			BookingDate: booking.BookingDate.Format("2006-01-02"),
-			StartTime:   booking.StartTime.Format("15:04"),
-			EndTime:     booking.EndTime.Format("15:04"),
-			Status:      booking.Status,
-			CreatedAt:   booking.CreatedAt,
-		})
+		response = append(response, toBookingResponse(booking, zones.of(booking.VenueID)))
 	}
 
 	// ส่งข้อมูลกลับ
@@ -236,10 +190,9 @@ func (h *Handler) CancelBooking(c *gin.Context) {
 		return
 	}
 
-	// ตรวจสอบสิทธิ์ (ยกเลิกได้เฉพาะเจ้าของหรือ admin)
+	// ตรวจสอบสิทธิ์ (ยกเลิกได้เฉพาะเจ้าของหรือผู้ที่มีสิทธิ์ยกเลิกการจองของผู้อื่นในสนามนี้)
 	isOwner := booking.StudentID == userClaims.StudentID
-	isAdmin := userClaims.Role == "admin"
-	if !isOwner && !isAdmin {
+	if !isOwner && !(rbac.Can(userClaims.Role, rbac.BookingsCancelAny) && canAccessVenue(userClaims, booking.VenueID)) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to cancel this booking"})
 		return
 	}
@@ -249,37 +202,299 @@ func (h *Handler) CancelBooking(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Booking is already cancelled"})
 		return
 	}
+	if booking.Status != "active" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only active bookings can be cancelled"})
+		return
+	}
+
+	// เหตุผลไม่บังคับสำหรับเจ้าของ จึงส่งคำขอโดยไม่มี body ได้
+	var req models.CancelBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	p, err := h.settingsRepo.GetBookingPolicy(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load booking policy"})
+		return
+	}
+
+	// ตรวจสอบกฎการยกเลิก (ยกเลิกหลังเวลาเริ่มไม่ได้ ยกเลิกช้านับรวมกับการไม่มาใช้คอร์ท)
+	cancellation, err := h.newCancellation(userClaims, booking, req.Reason, p)
+	if err != nil {
+		respondSlotError(c, err)
+		return
+	}
 
-	// ยกเลิกการจอง
-	if err := h.bookingRepo.CancelBooking(c.Request.Context(), id); err != nil {
+	// ยกเลิกการจองพร้อมบันทึกอีเมลแจ้งการยกเลิกลง outbox
+	notice := repository.NewOutboxMessage(notify.TemplateBookingCancellation, booking)
+	if err := h.bookingRepo.CancelBooking(c.Request.Context(), id, cancellation, notice); err != nil {
+		if errors.Is(err, repository.ErrBookingChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Booking was changed, please refresh"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel booking"})
 		return
 	}
 
+	booking.Status = "cancelled"
+	booking.Cancellation = &cancellation
+	h.emitBookingEvent(c.Request.Context(), webhook.EventBookingCancelled, booking)
+
+	// ให้ผู้ที่รอคิวช่วงเวลานี้ได้คอร์ทแทน
+	h.promoteWaitlistAsync(booking)
+
+	if cancellation.Late {
+		h.applyNoShowBan(c.Request.Context(), p, booking, cancellation.CancelledAt)
+	}
+
 	// ส่งข้อมูลกลับ
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Booking cancelled successfully",
+		"message":      "Booking cancelled successfully",
+		"cancellation": cancellation,
 	})
 }
 
-// GetAllBookings ดึงข้อมูลการจองทั้งหมด (สำหรับ admin)
+// GetAllBookings ดึงข้อมูลการจองทั้งหมดของสนามที่ดูแล (สำหรับ admin)
+// รองรับ query: venueId, dateFrom, dateTo, courtNumber, studentId, status, sortBy, order, cursor, limit
 func (h *Handler) GetAllBookings(c *gin.Context) {
-	// โค้ดสำหรับดึงข้อมูลการจองทั้งหมด
-	// (คุณสามารถเพิ่มการดึงข้อมูลการจองทั้งหมดได้ตามต้องการ)
-	c.JSON(http.StatusOK, gin.H{"message": "Admin bookings endpoint"})
+	venueIDs, ok := adminVenueScope(c)
+	if !ok {
+		return
+	}
+
+	filter := repository.BookingListFilter{
+		VenueIDs:  venueIDs,
+		StudentID: c.Query("studentId"),
+		Status:    c.Query("status"),
+		SortBy:    c.DefaultQuery("sortBy", "startTime"),
+		Cursor:    c.Query("cursor"),
+		Limit:     20,
+	}
+
+	zones, err := h.loadVenueZones(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookings"})
+		return
+	}
+
+	// แปลงช่วงวันที่ตาม timezone ของสนามที่เลือก (หลายสนามใช้ timezone จาก config)
+	dateLoc := h.location
+	if len(venueIDs) == 1 {
+		dateLoc = zones.of(venueIDs[0])
+	}
+	for _, p := range []struct {
+		name   string
+		target **time.Time
+	}{{"dateFrom", &filter.DateFrom}, {"dateTo", &filter.DateTo}} {
+		value := c.Query(p.name)
+		if value == "" {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", value, dateLoc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + p.name + " format, use YYYY-MM-DD"})
+			return
+		}
+		*p.target = &date
+	}
+
+	if courtStr := c.Query("courtNumber"); courtStr != "" {
+		courtNumber, err := strconv.Atoi(courtStr)
+		if err != nil || courtNumber < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid court number"})
+			return
+		}
+		filter.CourtNumber = courtNumber
+	}
+
+	switch filter.Status {
+	case "", "active", "cancelled", "completed", "no_show":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be one of active, cancelled, completed, no_show"})
+		return
+	}
+
+	if _, ok := repository.BookingSortFields[filter.SortBy]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sortBy must be one of startTime, createdAt, courtNumber"})
+		return
+	}
+
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	// อัปเดตสถานะการจองที่สิ้นสุดแล้วก่อน เพื่อให้ filter ตามสถานะได้ถูกต้อง
+	h.CompleteEndedBookings(c.Request.Context())
+
+	bookings, nextCursor, err := h.bookingRepo.FindAll(c.Request.Context(), filter)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching bookings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookings"})
+		return
+	}
+
+	rows, err := h.adminBookingRows(c.Request.Context(), bookings)
+	if err != nil {
+		log.Printf("Error fetching booking users: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookings"})
+		return
+	}
+
+	response := models.AdminBookingListResponse{
+		Bookings:   rows,
+		NextCursor: nextCursor,
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
-// CheckAvailability ตรวจสอบว่าคอร์ทว่างหรือไม่
+// adminBookingRows แปลงการจองเป็นรูปแบบสำหรับ admin พร้อมชื่อและอีเมลของผู้จอง
+func (h *Handler) adminBookingRows(ctx context.Context, bookings []*models.Booking) ([]models.AdminBookingResponse, error) {
+	zones, err := h.loadVenueZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// ดึงชื่อและอีเมลของผู้จองทั้งหมดในครั้งเดียว
+	userIDs := make([]primitive.ObjectID, 0, len(bookings))
+	for _, booking := range bookings {
+		userIDs = append(userIDs, booking.UserID)
+	}
+	users, err := h.userRepo.FindByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]models.AdminBookingResponse, 0, len(bookings))
+	for _, booking := range bookings {
+		row := models.AdminBookingResponse{
+			BookingResponse: toBookingResponse(booking, zones.of(booking.VenueID)),
+			StudentID:       booking.StudentID,
+		}
+		if user, ok := users[booking.UserID]; ok {
+			row.UserName = user.Name
+			row.UserEmail = user.Email
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// CheckAvailability ตรวจสอบว่าคอร์ทว่างหรือไม่ ด้วยกฎเดียวกับ CreateBooking
+// ถ้าไม่ระบุ courtNumber จะตรวจสอบทุกคอร์ทที่เปิดใช้งาน
 func (h *Handler) CheckAvailability(c *gin.Context) {
-	// โค้ดสำหรับตรวจสอบว่าคอร์ทว่างหรือไม่
-	// (คุณสามารถเพิ่มการตรวจสอบได้ตามต้องการ)
-	c.JSON(http.StatusOK, gin.H{"message": "Check availability endpoint"})
+	var req models.AvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	venue, err := h.resolveVenue(ctx, req.VenueID)
+	if err != nil {
+		respondSlotError(c, err)
+		return
+	}
+
+	var courts []*models.Court
+	var slot *bookingSlot
+	if req.CourtNumber != 0 {
+		slot, err = h.validateBookingSlot(ctx, venue, req.CourtNumber, req.BookingDate, req.StartTime, req.EndTime)
+		if err != nil {
+			respondSlotError(c, err)
+			return
+		}
+		courts = []*models.Court{slot.Court}
+	} else {
+		slot, err = h.validateSlotTimes(ctx, venue, req.BookingDate, req.StartTime, req.EndTime)
+		if err != nil {
+			respondSlotError(c, err)
+			return
+		}
+		courts, err = h.courtRepo.FindActiveCourts(ctx, venue.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch courts"})
+			return
+		}
+	}
+
+	response := models.AvailabilityResponse{
+		VenueID:     venue.ID.Hex(),
+		BookingDate: req.BookingDate,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		Courts:      make([]*models.CourtAvailability, 0, len(courts)),
+	}
+
+	loc := h.venueLocation(venue)
+	for _, court := range courts {
+		conflicts, err := h.bookingRepo.FindConflicts(ctx, venue.ID, court.CourtNumber, slot.BookingDate, slot.StartTime, slot.EndTime)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check court availability"})
+			return
+		}
+
+		blackouts, err := h.blackoutRepo.FindOverlapping(ctx, venue.ID, court.CourtNumber, slot.StartTime, slot.EndTime)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check court availability"})
+			return
+		}
+
+		availability := &models.CourtAvailability{
+			CourtNumber: court.CourtNumber,
+			IsAvailable: len(conflicts) == 0 && len(blackouts) == 0,
+		}
+		for _, booking := range conflicts {
+			availability.Conflicts = append(availability.Conflicts, models.TimeWindow{
+				StartTime: booking.StartTime.In(loc).Format("15:04"),
+				EndTime:   booking.EndTime.In(loc).Format("15:04"),
+			})
+		}
+		for _, blackout := range blackouts {
+			availability.Conflicts = append(availability.Conflicts, models.TimeWindow{
+				StartTime: blackout.StartTime.In(loc).Format("15:04"),
+				EndTime:   blackout.EndTime.In(loc).Format("15:04"),
+				Reason:    blackout.Reason,
+			})
+		}
+		response.Courts = append(response.Courts, availability)
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
-func SendMail(bookingRepo *repository.BookingRepository, userRepo *repository.UserRepository) {
-	log.Println("Starting SendMail function...")
+// reminderLeadTime คือเวลาก่อนเริ่มการจองที่ส่งอีเมลเตือน
+const reminderLeadTime = 15 * time.Minute
+
+// SendReminders นำอีเมลเตือนการจองที่จะเริ่มภายใน 15 นาทีเข้า outbox
+// การส่งจริงทำโดย DeliverOutbox ซึ่ง retry ให้เมื่อส่งไม่สำเร็จ
+func (h *Handler) SendReminders(ctx context.Context) {
+	log.Println("Starting SendReminders...")
 
-	bookings, err := bookingRepo.FindUpcomingBookings(context.Background(), time.Now().Add(15*time.Minute))
+	now := h.clock.Now()
+	bookings, err := h.bookingRepo.FindUpcomingBookings(ctx, now, now.Add(reminderLeadTime))
 	if err != nil {
 		log.Printf("Error fetching upcoming bookings: %v", err)
 		return
@@ -287,70 +502,36 @@ func SendMail(bookingRepo *repository.BookingRepository, userRepo *repository.Us
 
 	log.Printf("Found %d upcoming bookings", len(bookings))
 
-	// ตั้งค่า SMTP
-	auth := smtp.PlainAuth(
-		"",
-		"natthawat48.noi@gmail.com", // อีเมลผู้ส่ง
-		"mjxn rpse favy jidl",       // App Password
-		"smtp.gmail.com",            // Host ของ SMTP
-	)
-
 	for _, booking := range bookings {
-		log.Printf("Processing booking ID: %s", booking.ID.Hex())
+		reminder := repository.NewOutboxMessage(notify.TemplateReminder, booking)
 
-		// ดึงอีเมลของผู้ใช้จาก StudentID
-		user, err := userRepo.FindByStudentID(context.Background(), booking.StudentID)
+		// ผู้ใช้ที่ยังไม่ยืนยันอีเมลจะไม่ได้รับอีเมลเตือน
+		user, err := h.userRepo.FindByStudentID(ctx, booking.StudentID)
 		if err != nil {
-			log.Printf("Error fetching user email for StudentID %s: %v", booking.StudentID, err)
+			log.Printf("Error fetching user %s for reminder: %v", booking.StudentID, err)
 			continue
 		}
+		if user.EmailVerifiedAt == nil {
+			reminder = nil
+		}
 
-		log.Printf("Sending email to: %s", user.Email)
-
-		// สร้างข้อความอีเมล
-		msg := []byte(fmt.Sprintf(
-			"To: %s\r\nSubject: Upcoming Booking Reminder\r\n\r\nDear user,\n\nThis is a reminder for your upcoming booking:\n\nCourt Number: %d\nDate: %s\nTime: %s - %s\n\nThank you for using Courtminton!",
-			user.Email,
-			booking.CourtNumber,
-			booking.BookingDate.Format("2006-01-02"),
-			booking.StartTime.Format("15:04"),
-			booking.EndTime.Format("15:04"),
-		))
-
-		// ส่งอีเมล
-		err = smtp.SendMail(
-			"smtp.gmail.com:587",
-			auth,
-			"natthawat48.noi@gmail.com",
-			[]string{user.Email},
-			msg,
-		)
-
+		queued, err := h.bookingRepo.QueueReminder(ctx, booking, reminder)
 		if err != nil {
-			log.Printf("Error sending email to %s: %v", user.Email, err)
+			log.Printf("Error queueing reminder for booking ID %s: %v", booking.ID.Hex(), err)
 			continue
 		}
-
-		log.Printf("Email sent successfully to: %s", user.Email)
-
-		// อัปเดตสถานะการแจ้งเตือนในฐานข้อมูล
-		booking.NotificationSent = true
-		if err := bookingRepo.UpdateBooking(context.Background(), booking); err != nil {
-			log.Printf("Error updating notification status for booking ID %s: %v", booking.ID.Hex(), err)
-		} else {
-			log.Printf("Notification status updated for booking ID: %s", booking.ID.Hex())
+		if queued {
+			log.Printf("Reminder queued for booking ID: %s", booking.ID.Hex())
 		}
 	}
 
-	log.Println("SendMail function completed.")
+	log.Println("SendReminders completed.")
 }
 
 func (h *Handler) TriggerEmailNotifications(c *gin.Context) {
-	// เรียกใช้งาน SendMail
-	SendMail(h.bookingRepo, h.userRepo)
+	// เรียกใช้งาน SendReminders
+	h.SendReminders(c.Request.Context())
 
 	// ส่งข้อความกลับไปยัง client
 	c.JSON(http.StatusOK, gin.H{"message": "Email notifications triggered"})
 }
-
-
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/policy"
+	"courtopia-reserve/backend/internal/rbac"
+	"courtopia-reserve/backend/pkg/utils"
+)
+
+// maxBookingDuration is the longest a single booking may last
+const maxBookingDuration = 2 * time.Hour
+
+// slotError is a booking validation failure together with the HTTP status to respond with
+type slotError struct {
+	status  int
+	message string
+}
+
+func (e *slotError) Error() string {
+	return e.message
+}
+
+// bookingSlot is a validated court and time window
+type bookingSlot struct {
+	Venue       *models.Venue
+	Court       *models.Court
+	BookingDate time.Time
+	StartTime   time.Time
+	EndTime     time.Time
+}
+
+// parseSlotTimes แปลงวันที่ (YYYY-MM-DD) และเวลา (HH:MM) ตามเวลาท้องถิ่นของสนามให้เป็นช่วงเวลาบนวันนั้น
+func parseSlotTimes(loc *time.Location, dateStr, startStr, endStr string) (bookingDate, startTime, endTime time.Time, err *slotError) {
+	bookingDate, perr := time.ParseInLocation("2006-01-02", dateStr, loc)
+	if perr != nil {
+		return bookingDate, startTime, endTime, &slotError{http.StatusBadRequest, "Invalid date format, use YYYY-MM-DD"}
+	}
+
+	// รูปแบบเวลา
+	layout := "15:04"
+	startTimeParsed, perr := time.Parse(layout, startStr)
+	if perr != nil {
+		return bookingDate, startTime, endTime, &slotError{http.StatusBadRequest, "Invalid start time format, use HH:MM"}
+	}
+
+	endTimeParsed, perr := time.Parse(layout, endStr)
+	if perr != nil {
+		return bookingDate, startTime, endTime, &slotError{http.StatusBadRequest, "Invalid end time format, use HH:MM"}
+	}
+
+	// สร้าง datetime objects สำหรับช่วงเวลาที่ต้องการจอง
+	startTime = time.Date(bookingDate.Year(), bookingDate.Month(), bookingDate.Day(),
+		startTimeParsed.Hour(), startTimeParsed.Minute(), 0, 0, bookingDate.Location())
+	endTime = time.Date(bookingDate.Year(), bookingDate.Month(), bookingDate.Day(),
+		endTimeParsed.Hour(), endTimeParsed.Minute(), 0, 0, bookingDate.Location())
+
+	return bookingDate, startTime, endTime, nil
+}
+
+// validateSlotTimes แปลงและตรวจสอบช่วงเวลาตามกฎการจอง
+// (ต้องเป็นเวลาในอนาคต เวลาสิ้นสุดหลังเวลาเริ่ม ไม่เกิน 2 ชั่วโมง และอยู่ในเวลาทำการ)
+func (h *Handler) validateSlotTimes(ctx context.Context, venue *models.Venue, dateStr, startStr, endStr string) (*bookingSlot, error) {
+	bookingDate, startTime, endTime, perr := parseSlotTimes(h.venueLocation(venue), dateStr, startStr, endStr)
+	if perr != nil {
+		return nil, perr
+	}
+
+	if startTime.Before(h.clock.Now()) {
+		return nil, &slotError{http.StatusBadRequest, "Booking time must be in the future"}
+	}
+
+	if !endTime.After(startTime) {
+		return nil, &slotError{http.StatusBadRequest, "End time must be after start time"}
+	}
+
+	if endTime.Sub(startTime) > maxBookingDuration {
+		return nil, &slotError{http.StatusBadRequest, "Booking duration cannot exceed 2 hours"}
+	}
+
+	hours, err := h.settingsRepo.GetOperatingHours(ctx, venue.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOperatingHours(hours, startTime, endTime); err != nil {
+		return nil, err
+	}
+
+	return &bookingSlot{Venue: venue, BookingDate: bookingDate, StartTime: startTime, EndTime: endTime}, nil
+}
+
+// clockMinutes แปลงเวลา HH:MM เป็นจำนวนนาทีนับจากเที่ยงคืน
+func clockMinutes(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// hoursForDay คืนเวลาทำการของวันในสัปดาห์ที่กำหนด
+func hoursForDay(hours *models.OperatingHours, weekday time.Weekday) (models.DayHours, bool) {
+	for _, day := range hours.Days {
+		if day.Weekday == int(weekday) {
+			return day, true
+		}
+	}
+	return models.DayHours{}, false
+}
+
+// checkOperatingHours ตรวจสอบว่าช่วงเวลาอยู่ในเวลาทำการ ตรงกับขนาด slot และยาวไม่น้อยกว่าขั้นต่ำ
+func checkOperatingHours(hours *models.OperatingHours, startTime, endTime time.Time) *slotError {
+	day, ok := hoursForDay(hours, startTime.Weekday())
+	if !ok || day.IsClosed {
+		return &slotError{http.StatusBadRequest, "The venue is closed on the selected date"}
+	}
+
+	openMinutes, err := clockMinutes(day.OpenTime)
+	if err != nil {
+		return &slotError{http.StatusInternalServerError, "Invalid operating hours configuration"}
+	}
+	closeMinutes, err := clockMinutes(day.CloseTime)
+	if err != nil {
+		return &slotError{http.StatusInternalServerError, "Invalid operating hours configuration"}
+	}
+
+	startMinutes := startTime.Hour()*60 + startTime.Minute()
+	endMinutes := endTime.Hour()*60 + endTime.Minute()
+	if startMinutes < openMinutes || endMinutes > closeMinutes {
+		return &slotError{http.StatusBadRequest, fmt.Sprintf("Booking must be within opening hours (%s-%s)", day.OpenTime, day.CloseTime)}
+	}
+
+	if hours.SlotMinutes > 0 && (startMinutes%hours.SlotMinutes != 0 || endMinutes%hours.SlotMinutes != 0) {
+		return &slotError{http.StatusBadRequest, fmt.Sprintf("Booking times must be on %d-minute boundaries", hours.SlotMinutes)}
+	}
+
+	if endMinutes-startMinutes < hours.MinDurationMinutes {
+		return &slotError{http.StatusBadRequest, fmt.Sprintf("Booking duration must be at least %d minutes", hours.MinDurationMinutes)}
+	}
+
+	return nil
+}
+
+// findBookableCourt ค้นหาคอร์ทของสนามจากเลขคอร์ทและตรวจสอบว่าเปิดให้จองอยู่
+func (h *Handler) findBookableCourt(ctx context.Context, venueID primitive.ObjectID, courtNumber int) (*models.Court, error) {
+	court, err := h.courtRepo.FindByCourtNumber(ctx, venueID, courtNumber)
+	if err == mongo.ErrNoDocuments {
+		return nil, &slotError{http.StatusNotFound, "Court not found"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !court.IsActive {
+		return nil, &slotError{http.StatusBadRequest, "Court is not available for booking"}
+	}
+
+	return court, nil
+}
+
+// validateBookingSlot ตรวจสอบคำขอจองด้วยกฎชุดเดียวกับที่ CreateBooking และ CheckAvailability ใช้
+func (h *Handler) validateBookingSlot(ctx context.Context, venue *models.Venue, courtNumber int, dateStr, startStr, endStr string) (*bookingSlot, error) {
+	slot, err := h.validateSlotTimes(ctx, venue, dateStr, startStr, endStr)
+	if err != nil {
+		return nil, err
+	}
+
+	slot.Court, err = h.findBookableCourt(ctx, venue.ID, courtNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return slot, nil
+}
+
+// checkBookingPolicy ตรวจสอบคำขอจองกับกฎการใช้งานที่เป็นธรรม (จำนวนการจอง ชั่วโมงต่อวัน/สัปดาห์ ฯลฯ)
+// การจองที่กำลังถูกย้าย (excludeID) ไม่นับรวมในการใช้งานเดิม
+func (h *Handler) checkBookingPolicy(ctx context.Context, studentID string, courtNumber int, slot *bookingSlot, excludeID primitive.ObjectID) error {
+	p, err := h.settingsRepo.GetBookingPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.userRepo.FindByStudentID(ctx, studentID)
+	if err != nil {
+		return err
+	}
+
+	active, err := h.bookingRepo.FindActiveBookingsByStudentID(ctx, studentID)
+	if err != nil {
+		return err
+	}
+
+	// ดึงการจองก่อนต้นสัปดาห์เผื่อไว้ด้วย เพราะการจองที่เริ่มก่อนอาจยังทับช่วงเวลาที่ขอ
+	weekStart, weekEnd := policy.WeekRange(slot.StartTime)
+	bookings, err := h.bookingRepo.FindUserBookingsBetween(ctx, studentID, weekStart.Add(-maxBookingDuration), weekEnd)
+	if err != nil {
+		return err
+	}
+
+	if !excludeID.IsZero() {
+		isExcluded := func(b *models.Booking) bool { return b.ID == excludeID }
+		active = slices.DeleteFunc(active, isExcluded)
+		bookings = slices.DeleteFunc(bookings, isExcluded)
+	}
+
+	req := policy.Request{
+		VenueID:     slot.Venue.ID,
+		CourtNumber: courtNumber,
+		StartTime:   slot.StartTime,
+		EndTime:     slot.EndTime,
+		Now:         h.clock.Now(),
+	}
+	if v := policy.Evaluate(p, req, policy.Usage{
+		ActiveBookings: len(active),
+		Bookings:       bookings,
+		BannedUntil:    user.BookingBannedUntil,
+		EmailVerified:  user.EmailVerifiedAt != nil,
+	}); v != nil {
+		return v
+	}
+
+	return nil
+}
+
+// newCancellation ใช้กฎการยกเลิกกับผู้ที่ยกเลิกการจอง
+// ยกเลิกได้จนถึงเวลาเริ่ม ถ้าเจ้าของยกเลิกเมื่อเหลือเวลาน้อยกว่า CancelCutoffHours จะนับเป็นการยกเลิกช้า
+// การยกเลิกการจองของผู้อื่นต้องระบุเหตุผลและไม่นับเป็นการยกเลิกช้าของเจ้าของ
+// ผู้ที่มีสิทธิ์ bypass ของสนามนี้และระบุเหตุผลไม่ติดกฎเหล่านี้
+func (h *Handler) newCancellation(claims *utils.Claims, booking *models.Booking, reason string, p *models.BookingPolicy) (models.BookingCancellation, error) {
+	now := h.clock.Now()
+	cancellation := models.BookingCancellation{
+		CancelledBy: claims.StudentID,
+		CancelledAt: now,
+		Reason:      strings.TrimSpace(reason),
+	}
+
+	if cancellation.Reason != "" && rbac.Can(claims.Role, rbac.BookingsBypassPolicy) && canAccessVenue(claims, booking.VenueID) {
+		cancellation.BypassedPolicy = true
+		return cancellation, nil
+	}
+
+	isOwner := booking.StudentID == claims.StudentID
+	if !isOwner && cancellation.Reason == "" {
+		return cancellation, &slotError{http.StatusBadRequest, "A reason is required to cancel someone else's booking"}
+	}
+
+	if !booking.StartTime.After(now) {
+		return cancellation, &slotError{http.StatusBadRequest, "Booking has already started and cannot be cancelled"}
+	}
+	cancellation.Late = isOwner && booking.StartTime.Sub(now) < time.Duration(p.CancelCutoffHours)*time.Hour
+
+	return cancellation, nil
+}
+
+// respondSlotError ส่ง response ตามชนิดของ error ที่ได้จากการตรวจสอบ
+func respondSlotError(c *gin.Context, err error) {
+	var se *slotError
+	if errors.As(err, &se) {
+		c.JSON(se.status, gin.H{"error": se.message})
+		return
+	}
+	var v *policy.Violation
+	if errors.As(err, &v) {
+		c.JSON(http.StatusBadRequest, v)
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check court availability"})
+}
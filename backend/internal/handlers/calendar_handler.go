@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"courtopia-reserve/backend/internal/calendar"
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/rbac"
+	"courtopia-reserve/backend/pkg/utils"
+)
+
+// bookingEvent แปลงการจองเป็น event ของปฏิทิน โดยใส่ชื่อและที่อยู่ของสนามไว้ใน location (venue เป็น nil ได้)
+// UID มาจาก Booking.ID จึงคงที่ตลอดอายุการจอง ทำให้ปฏิทินอัปเดต event เดิมแทนการสร้างใหม่
+func bookingEvent(booking *models.Booking, venue *models.Venue) calendar.Event {
+	status := calendar.StatusConfirmed
+	if booking.Status == "cancelled" {
+		status = calendar.StatusCancelled
+	}
+
+	location := []string{fmt.Sprintf("Court %d", booking.CourtNumber)}
+	if venue != nil {
+		location = append(location, venue.Name)
+		if venue.Address != "" {
+			location = append(location, venue.Address)
+		}
+	}
+
+	return calendar.Event{
+		UID:          booking.ID.Hex() + "@courtminton",
+		Summary:      fmt.Sprintf("Badminton - Court %d", booking.CourtNumber),
+		Description:  fmt.Sprintf("Courtminton booking %s", booking.ID.Hex()),
+		Location:     strings.Join(location, ", "),
+		Start:        booking.StartTime,
+		End:          booking.EndTime,
+		Status:       status,
+		Sequence:     bookingSequence(booking),
+		Created:      booking.CreatedAt,
+		LastModified: booking.UpdatedAt,
+	}
+}
+
+// bookingSequence คือ SEQUENCE ของ event ซึ่งต้องเพิ่มขึ้นทุกครั้งที่เวลา คอร์ท หรือสถานะของการจองเปลี่ยน
+// นับจากจำนวนครั้งที่ย้ายการจอง และบวกอีกหนึ่งเมื่อถูกยกเลิก (การจองที่ยกเลิกแล้วย้ายต่อไม่ได้)
+func bookingSequence(booking *models.Booking) int {
+	sequence := len(booking.Changes)
+	if booking.Status == "cancelled" {
+		sequence++
+	}
+	return sequence
+}
+
+// bookingICS สร้างไฟล์ .ics ของการจองหนึ่งรายการ
+func bookingICS(booking *models.Booking, venue *models.Venue) []byte {
+	return calendar.Calendar{
+		Method: "PUBLISH",
+		Events: []calendar.Event{bookingEvent(booking, venue)},
+	}.Bytes()
+}
+
+// bookingICSFilename ตั้งชื่อไฟล์ .ics ของการจอง
+func bookingICSFilename(booking *models.Booking) string {
+	return fmt.Sprintf("booking-%s.ics", booking.ID.Hex())
+}
+
+// DownloadBookingICS ดาวน์โหลดการจองเป็นไฟล์ .ics (เฉพาะเจ้าของหรือผู้ที่ดูการจองทั้งหมดได้)
+func (h *Handler) DownloadBookingICS(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	booking, err := h.bookingRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		return
+	}
+
+	if booking.StudentID != claims.StudentID && !(rbac.Can(claims.Role, rbac.BookingsReadAll) && canAccessVenue(claims, booking.VenueID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to view this booking"})
+		return
+	}
+
+	venue, err := h.venueRepo.FindByID(c.Request.Context(), booking.VenueID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch venue"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", bookingICSFilename(booking)))
+	c.Data(http.StatusOK, calendar.ContentType, bookingICS(booking, venue))
+}
+
+// GetCalendarFeed คืน URL ของ calendar feed ของผู้ใช้ และสร้าง token ให้ถ้ายังไม่มี
+func (h *Handler) GetCalendarFeed(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	user, err := h.userRepo.FindByStudentID(c.Request.Context(), claims.StudentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	token := user.CalendarToken
+	if token == "" {
+		token, err = h.rotateCalendarToken(c.Request.Context(), user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar feed"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": calendarFeedURL(c, token)})
+}
+
+// ResetCalendarFeed สร้าง token ใหม่ เพื่อยกเลิก URL เดิมที่อาจหลุดไป
+func (h *Handler) ResetCalendarFeed(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	user, err := h.userRepo.FindByStudentID(c.Request.Context(), claims.StudentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	token, err := h.rotateCalendarToken(c.Request.Context(), user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset calendar feed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": calendarFeedURL(c, token)})
+}
+
+// CalendarFeed ส่งการจองที่ยังไม่จบของเจ้าของ token เป็น VCALENDAR
+// การจองที่ถูกยกเลิกส่งไปด้วยเป็น STATUS:CANCELLED เพื่อให้แอปปฏิทินลบ event ที่เคยดึงไปแล้ว
+// route นี้ไม่ต้อง login เพราะแอปปฏิทินส่ง Authorization header ไม่ได้ token ใน URL จึงเป็นตัวยืนยันตัวตน
+func (h *Handler) CalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	if token == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendar not found"})
+		return
+	}
+
+	user, err := h.userRepo.FindByCalendarToken(c.Request.Context(), token)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendar not found"})
+		return
+	}
+
+	bookings, err := h.bookingRepo.FindCalendarBookingsByStudentID(c.Request.Context(), user.StudentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookings"})
+		return
+	}
+
+	venues, err := h.venueRepo.FindAll(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch venues"})
+		return
+	}
+	venueByID := make(map[primitive.ObjectID]*models.Venue, len(venues))
+	for _, venue := range venues {
+		venueByID[venue.ID] = venue
+	}
+
+	feed := calendar.Calendar{
+		Name:   "Courtminton - " + user.Name,
+		Method: "PUBLISH",
+	}
+	for _, booking := range bookings {
+		feed.Events = append(feed.Events, bookingEvent(booking, venueByID[booking.VenueID]))
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, calendar.ContentType, feed.Bytes())
+}
+
+// rotateCalendarToken สร้างและบันทึก token ใหม่ของ calendar feed
+func (h *Handler) rotateCalendarToken(ctx context.Context, user *models.User) (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(random)
+
+	if err := h.userRepo.SetCalendarToken(ctx, user.ID, token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// calendarFeedURL สร้าง URL เต็มของ feed จาก host ที่ client เรียกเข้ามา
+func calendarFeedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return fmt.Sprintf("%s://%s/api/calendar/%s.ics", scheme, c.Request.Host, token)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"courtopia-reserve/backend/internal/calendar"
+	"courtopia-reserve/backend/internal/clock"
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/rbac"
+)
+
+// TestBookingEventSequence ตรวจว่า SEQUENCE เพิ่มขึ้นเมื่อย้ายหรือยกเลิกการจองเท่านั้น ไม่ขึ้นกับเวลาที่แก้ไข
+func TestBookingEventSequence(t *testing.T) {
+	created := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	change := models.BookingChange{ChangedBy: "65000001", ChangedAt: created.Add(time.Hour)}
+
+	tests := []struct {
+		name         string
+		booking      models.Booking
+		wantSequence int
+		wantStatus   string
+	}{
+		{
+			name:         "new booking",
+			booking:      models.Booking{Status: "active"},
+			wantSequence: 0,
+			wantStatus:   calendar.StatusConfirmed,
+		},
+		{
+			// เช็กอินหรือส่งอีเมลแจ้งเตือนเปลี่ยน updated_at แต่ event ในปฏิทินไม่เปลี่ยน
+			name:         "updated without changes",
+			booking:      models.Booking{Status: "active", UpdatedAt: created.Add(48 * time.Hour)},
+			wantSequence: 0,
+			wantStatus:   calendar.StatusConfirmed,
+		},
+		{
+			name:         "moved twice",
+			booking:      models.Booking{Status: "active", Changes: []models.BookingChange{change, change}},
+			wantSequence: 2,
+			wantStatus:   calendar.StatusConfirmed,
+		},
+		{
+			name:         "cancelled",
+			booking:      models.Booking{Status: "cancelled"},
+			wantSequence: 1,
+			wantStatus:   calendar.StatusCancelled,
+		},
+		{
+			name:         "moved then cancelled",
+			booking:      models.Booking{Status: "cancelled", Changes: []models.BookingChange{change}},
+			wantSequence: 2,
+			wantStatus:   calendar.StatusCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booking := tt.booking
+			booking.ID = primitive.NewObjectID()
+			booking.CreatedAt = created
+			if booking.UpdatedAt.IsZero() {
+				booking.UpdatedAt = created
+			}
+
+			event := bookingEvent(&booking, nil)
+			if event.Sequence != tt.wantSequence {
+				t.Errorf("got SEQUENCE %d, want %d", event.Sequence, tt.wantSequence)
+			}
+			if event.Status != tt.wantStatus {
+				t.Errorf("got STATUS %s, want %s", event.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// TestCalendarFeedIncludesCancelledBookings ตรวจว่า feed มีการจองที่ถูกยกเลิกซึ่งยังไม่ถึงเวลาเป็น STATUS:CANCELLED
+// แต่ไม่มีการจองที่จบไปแล้ว
+func TestCalendarFeedIncludesCancelledBookings(t *testing.T) {
+	h, db := newTestHandler(t)
+	ctx := context.Background()
+
+	venue, court := newTestCourt(t, h, 1)
+	loc := mustLocation(t, venue.Timezone)
+	now := time.Date(2030, 1, 7, 9, 0, 0, 0, loc)
+	h.UseClock(clock.Fixed(now))
+
+	newTestUser(t, h, "65000001", rbac.RoleStudent)
+	user, err := h.userRepo.FindByStudentID(ctx, "65000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.userRepo.SetCalendarToken(ctx, user.ID, "feed-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	newBooking := func(day int, status string) *models.Booking {
+		start := time.Date(2030, 1, day, 18, 0, 0, 0, loc)
+		booking := &models.Booking{
+			ID:          primitive.NewObjectID(),
+			UserID:      user.ID,
+			StudentID:   user.StudentID,
+			VenueID:     venue.ID,
+			CourtID:     court.ID,
+			CourtNumber: court.CourtNumber,
+			BookingDate: time.Date(2030, 1, day, 0, 0, 0, 0, loc),
+			StartTime:   start,
+			EndTime:     start.Add(time.Hour),
+			Status:      status,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if _, err := db.Collection("bookings").InsertOne(ctx, booking); err != nil {
+			t.Fatal(err)
+		}
+		return booking
+	}
+	active := newBooking(8, "active")
+	cancelled := newBooking(9, "cancelled")
+	past := newBooking(6, "cancelled")
+
+	router := gin.New()
+	h.RegisterRoutes(router)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/calendar/feed-token.ics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	events := map[string]string{}
+	for _, block := range strings.Split(rec.Body.String(), "BEGIN:VEVENT")[1:] {
+		var uid, status string
+		for _, line := range strings.Split(block, "\r\n") {
+			if value, ok := strings.CutPrefix(line, "UID:"); ok {
+				uid = value
+			}
+			if value, ok := strings.CutPrefix(line, "STATUS:"); ok {
+				status = value
+			}
+		}
+		events[uid] = status
+	}
+
+	if got := events[active.ID.Hex()+"@courtminton"]; got != calendar.StatusConfirmed {
+		t.Errorf("active booking: got STATUS %q, want %s", got, calendar.StatusConfirmed)
+	}
+	if got := events[cancelled.ID.Hex()+"@courtminton"]; got != calendar.StatusCancelled {
+		t.Errorf("cancelled booking: got STATUS %q, want %s", got, calendar.StatusCancelled)
+	}
+	if _, ok := events[past.ID.Hex()+"@courtminton"]; ok {
+		t.Error("feed includes a booking that has already ended")
+	}
+	if len(events) != 2 {
+		t.Errorf("got %d events, want 2", len(events))
+	}
+}
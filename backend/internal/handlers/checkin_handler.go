@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/rbac"
+	"courtopia-reserve/backend/internal/webhook"
+	"courtopia-reserve/backend/pkg/utils"
+)
+
+// courtCheckInCode คืนรหัสเช็กอินของคอร์ทสำหรับพิมพ์เป็น QR code ข้างคอร์ท
+// รหัสคำนวณจาก JWT secret จึงไม่ต้องเก็บในฐานข้อมูล
+func (h *Handler) courtCheckInCode(courtID primitive.ObjectID) string {
+	mac := hmac.New(sha256.New, []byte(h.jwtSecret))
+	mac.Write([]byte("checkin:" + courtID.Hex()))
+	return hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// CheckInBooking เช็กอินการจอง ผู้จองต้องส่งรหัสจาก QR code ของคอร์ท ส่วน staff และ admin เช็กอินให้ได้โดยไม่ต้องใช้รหัส
+func (h *Handler) CheckInBooking(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req struct {
+		CourtCode string `json:"courtCode"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	booking, err := h.bookingRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		return
+	}
+
+	isStaff := rbac.Can(claims.Role, rbac.BookingsCheckInAny) && canAccessVenue(claims, booking.VenueID)
+	if !isStaff {
+		if booking.StudentID != claims.StudentID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to check in this booking"})
+			return
+		}
+		if !hmac.Equal([]byte(req.CourtCode), []byte(h.courtCheckInCode(booking.CourtID))) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid court code, scan the QR code at the court"})
+			return
+		}
+	}
+
+	if booking.Status != "active" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Booking is not active"})
+		return
+	}
+	if booking.CheckedInAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Booking is already checked in"})
+		return
+	}
+
+	p, err := h.settingsRepo.GetBookingPolicy(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load booking policy"})
+		return
+	}
+
+	// เช็กอินได้ตั้งแต่ก่อนเวลาเริ่มตามที่กำหนด จนถึงหมดเวลาผ่อนผัน (หรือจนจบการจองถ้าไม่ติดตามการไม่มา)
+	now := h.clock.Now()
+	opens := booking.StartTime.Add(-time.Duration(p.CheckInOpensMinutes) * time.Minute)
+	closes := booking.EndTime
+	if p.CheckInGraceMinutes > 0 {
+		closes = booking.StartTime.Add(time.Duration(p.CheckInGraceMinutes) * time.Minute)
+	}
+	if now.Before(opens) || now.After(closes) {
+		loc, err := h.locationOf(c.Request.Context(), booking.VenueID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch venue"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Check-in is only possible between " + opens.In(loc).Format("15:04") + " and " + closes.In(loc).Format("15:04"),
+			"opens":  opens,
+			"closes": closes,
+		})
+		return
+	}
+
+	checkedIn, err := h.bookingRepo.CheckIn(c.Request.Context(), id, claims.StudentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check in"})
+		return
+	}
+	if !checkedIn {
+		c.JSON(http.StatusConflict, gin.H{"error": "Booking was changed, please refresh"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Checked in successfully"})
+}
+
+// GetCourtCheckInCode ดึงรหัสเช็กอินของคอร์ทสำหรับสร้าง QR code (สำหรับ staff และผู้ดูแลสนาม)
+func (h *Handler) GetCourtCheckInCode(c *gin.Context) {
+	court, ok := h.findCourt(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"courtNumber": court.CourtNumber,
+		"courtCode":   h.courtCheckInCode(court.ID),
+	})
+}
+
+// ProcessNoShows ปล่อยคอร์ทของการจองที่ไม่มีใครเช็กอินภายในเวลาผ่อนผัน
+// และห้ามจองชั่วคราวเมื่อผู้ใช้ไม่มาครบตามจำนวนที่กำหนด
+func (h *Handler) ProcessNoShows(ctx context.Context) {
+	p, err := h.settingsRepo.GetBookingPolicy(ctx)
+	if err != nil {
+		log.Printf("Error loading booking policy: %v", err)
+		return
+	}
+	if p.CheckInGraceMinutes <= 0 {
+		return
+	}
+
+	// การจองที่เริ่มก่อนเปิดใช้การตรวจไม่มาใช้คอร์ทไม่มีการเช็กอิน จึงไม่นับเป็น no-show
+	rollout, err := h.settingsRepo.NoShowRollout(ctx)
+	if err != nil {
+		log.Printf("Error loading no-show rollout time: %v", err)
+		return
+	}
+
+	now := h.clock.Now()
+	bookings, err := h.bookingRepo.FindNoShowCandidates(ctx, rollout, now.Add(-time.Duration(p.CheckInGraceMinutes)*time.Minute))
+	if err != nil {
+		log.Printf("Error fetching no-show candidates: %v", err)
+		return
+	}
+
+	for _, booking := range bookings {
+		marked, err := h.bookingRepo.MarkNoShow(ctx, booking.ID)
+		if err != nil {
+			log.Printf("Error marking booking %s as no-show: %v", booking.ID.Hex(), err)
+			continue
+		}
+		if !marked {
+			continue
+		}
+		log.Printf("Booking %s marked as no-show", booking.ID.Hex())
+		booking.Status = "no_show"
+		h.emitBookingEvent(ctx, webhook.EventBookingNoShow, booking)
+
+		// เวลาที่เหลือของการจองว่างแล้ว ให้คิวรอได้ใช้
+		h.promoteWaitlist(ctx, booking)
+
+		h.applyNoShowBan(ctx, p, booking, now)
+	}
+}
+
+// applyNoShowBan ห้ามผู้จองจองชั่วคราวเมื่อไม่มาใช้คอร์ทหรือยกเลิกช้าครบตามจำนวนที่กำหนดภายในช่วงเวลา
+func (h *Handler) applyNoShowBan(ctx context.Context, p *models.BookingPolicy, booking *models.Booking, now time.Time) {
+	if p.NoShowLimit <= 0 || p.NoShowBanDays <= 0 {
+		return
+	}
+
+	since := now.AddDate(0, 0, -p.NoShowWindowDays)
+	count, err := h.bookingRepo.CountNoShowsSince(ctx, booking.StudentID, since)
+	if err != nil {
+		log.Printf("Error counting no-shows for %s: %v", booking.StudentID, err)
+		return
+	}
+	if count < int64(p.NoShowLimit) {
+		return
+	}
+
+	until := now.AddDate(0, 0, p.NoShowBanDays)
+	if err := h.userRepo.SetBookingBan(ctx, booking.UserID, until); err != nil {
+		log.Printf("Error banning %s after no-shows: %v", booking.StudentID, err)
+		return
+	}
+	log.Printf("User %s banned from booking until %s after %d no-shows and late cancellations", booking.StudentID, until.Format(time.RFC3339), count)
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"courtopia-reserve/backend/internal/clock"
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/rbac"
+	"courtopia-reserve/backend/internal/repository"
+)
+
+// serverZones คือ timezone ของเครื่อง server ที่ใช้ทดสอบว่าผลลัพธ์ไม่ขึ้นกับ time.Local
+var serverZones = []string{"Asia/Bangkok", "UTC"}
+
+// withServerZone กำหนด time.Local ระหว่างการทดสอบและคืนค่าเดิมเมื่อจบ
+func withServerZone(t *testing.T, name string) {
+	t.Helper()
+	loc, err := clock.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = previous })
+}
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := clock.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestParseSlotTimes(t *testing.T) {
+	tests := []struct {
+		name       string
+		venueZone  string
+		date       string
+		start, end string
+		wantStart  time.Time
+		wantEnd    time.Time
+		wantStatus int
+	}{
+		{
+			name:      "bangkok evening",
+			venueZone: "Asia/Bangkok",
+			date:      "2030-01-07", start: "18:00", end: "19:30",
+			wantStart: time.Date(2030, 1, 7, 11, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2030, 1, 7, 12, 30, 0, 0, time.UTC),
+		},
+		{
+			name:      "bangkok early morning is the previous day in UTC",
+			venueZone: "Asia/Bangkok",
+			date:      "2030-01-07", start: "06:00", end: "07:00",
+			wantStart: time.Date(2030, 1, 6, 23, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "utc venue",
+			venueZone: "UTC",
+			date:      "2030-01-07", start: "18:00", end: "19:00",
+			wantStart: time.Date(2030, 1, 7, 18, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2030, 1, 7, 19, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "invalid date",
+			venueZone: "Asia/Bangkok",
+			date:      "07/01/2030", start: "18:00", end: "19:00",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "invalid start time",
+			venueZone: "Asia/Bangkok",
+			date:      "2030-01-07", start: "6pm", end: "19:00",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "invalid end time",
+			venueZone: "UTC",
+			date:      "2030-01-07", start: "18:00", end: "25:00",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, server := range serverZones {
+		for _, tt := range tests {
+			t.Run(server+"/"+tt.name, func(t *testing.T) {
+				withServerZone(t, server)
+				loc := mustLocation(t, tt.venueZone)
+
+				bookingDate, start, end, err := parseSlotTimes(loc, tt.date, tt.start, tt.end)
+				if tt.wantStatus != 0 {
+					if err == nil || err.status != tt.wantStatus {
+						t.Fatalf("got error %v, want status %d", err, tt.wantStatus)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+					t.Errorf("got %s-%s, want %s-%s", start.UTC(), end.UTC(), tt.wantStart, tt.wantEnd)
+				}
+				if got := bookingDate.In(loc).Format("2006-01-02"); got != tt.date {
+					t.Errorf("booking date is %s in the venue timezone, want %s", got, tt.date)
+				}
+			})
+		}
+	}
+}
+
+func TestCheckOperatingHours(t *testing.T) {
+	hours := repository.DefaultOperatingHours()
+	// วันจันทร์ปิดและวันอังคารเปิดตั้งแต่ 05:00 เพื่อทดสอบว่าวันในสัปดาห์คิดตาม timezone ของสนาม
+	hours.Days[time.Monday].IsClosed = true
+	hours.Days[time.Tuesday].OpenTime = "05:00"
+
+	tests := []struct {
+		name       string
+		venueZone  string
+		date       string
+		start, end string
+		wantStatus int
+	}{
+		{name: "open", venueZone: "Asia/Bangkok", date: "2030-01-08", start: "08:00", end: "09:00"},
+		{name: "until closing", venueZone: "UTC", date: "2030-01-08", start: "21:00", end: "22:00"},
+		{name: "before opening", venueZone: "Asia/Bangkok", date: "2030-01-09", start: "07:30", end: "08:30", wantStatus: http.StatusBadRequest},
+		{name: "after closing", venueZone: "UTC", date: "2030-01-08", start: "21:30", end: "22:30", wantStatus: http.StatusBadRequest},
+		{name: "off slot boundary", venueZone: "Asia/Bangkok", date: "2030-01-08", start: "10:15", end: "11:15", wantStatus: http.StatusBadRequest},
+		// 08:00 วันจันทร์ที่กรุงเทพฯ ตรงกับ 01:00 UTC ซึ่งก็ยังเป็นวันจันทร์
+		{name: "closed day", venueZone: "Asia/Bangkok", date: "2030-01-07", start: "08:00", end: "09:00", wantStatus: http.StatusBadRequest},
+		// 06:00 วันอังคารที่กรุงเทพฯ ตรงกับ 23:00 UTC วันจันทร์ ต้องคิดเป็นวันอังคารตามสนาม
+		{name: "weekday from venue timezone", venueZone: "Asia/Bangkok", date: "2030-01-08", start: "06:00", end: "07:00"},
+	}
+
+	for _, server := range serverZones {
+		for _, tt := range tests {
+			t.Run(server+"/"+tt.name, func(t *testing.T) {
+				withServerZone(t, server)
+
+				_, start, end, perr := parseSlotTimes(mustLocation(t, tt.venueZone), tt.date, tt.start, tt.end)
+				if perr != nil {
+					t.Fatalf("parse: %v", perr)
+				}
+				err := checkOperatingHours(hours, start, end)
+				if tt.wantStatus == 0 {
+					if err != nil {
+						t.Errorf("unexpected error: %v", err)
+					}
+					return
+				}
+				if err == nil || err.status != tt.wantStatus {
+					t.Errorf("got error %v, want status %d", err, tt.wantStatus)
+				}
+			})
+		}
+	}
+}
+
+func TestToBookingResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		venueZone string
+		start     time.Time
+		wantDate  string
+		wantStart string
+		wantEnd   string
+	}{
+		{
+			name:      "bangkok",
+			venueZone: "Asia/Bangkok",
+			start:     time.Date(2030, 1, 7, 11, 0, 0, 0, time.UTC),
+			wantDate:  "2030-01-07", wantStart: "18:00", wantEnd: "19:00",
+		},
+		{
+			name:      "bangkok next day",
+			venueZone: "Asia/Bangkok",
+			start:     time.Date(2030, 1, 7, 23, 0, 0, 0, time.UTC),
+			wantDate:  "2030-01-08", wantStart: "06:00", wantEnd: "07:00",
+		},
+		{
+			name:      "utc",
+			venueZone: "UTC",
+			start:     time.Date(2030, 1, 7, 23, 0, 0, 0, time.UTC),
+			wantDate:  "2030-01-07", wantStart: "23:00", wantEnd: "00:00",
+		},
+	}
+
+	for _, server := range serverZones {
+		for _, tt := range tests {
+			t.Run(server+"/"+tt.name, func(t *testing.T) {
+				withServerZone(t, server)
+				loc := mustLocation(t, tt.venueZone)
+
+				start := tt.start.In(loc)
+				booking := &models.Booking{
+					ID:          primitive.NewObjectID(),
+					BookingDate: time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc),
+					StartTime:   tt.start,
+					EndTime:     tt.start.Add(time.Hour),
+					Status:      "active",
+				}
+
+				got := toBookingResponse(booking, loc)
+				if got.BookingDate != tt.wantDate || got.StartTime != tt.wantStart || got.EndTime != tt.wantEnd {
+					t.Errorf("got %s %s-%s, want %s %s-%s", got.BookingDate, got.StartTime, got.EndTime, tt.wantDate, tt.wantStart, tt.wantEnd)
+				}
+			})
+		}
+	}
+}
+
+// TestValidateSlotTimesUsesClock ตรวจว่าการจองย้อนหลังเทียบกับเวลาจาก clock ของ Handler
+func TestValidateSlotTimesUsesClock(t *testing.T) {
+	h, _ := newTestHandler(t)
+	venue, _ := newTestCourt(t, h, 1)
+	loc := mustLocation(t, venue.Timezone)
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		wantStatus int
+	}{
+		{name: "before start", now: time.Date(2030, 1, 7, 17, 59, 0, 0, loc)},
+		{name: "at start", now: time.Date(2030, 1, 7, 18, 0, 0, 0, loc)},
+		{name: "after start", now: time.Date(2030, 1, 7, 18, 1, 0, 0, loc), wantStatus: http.StatusBadRequest},
+		{name: "next day", now: time.Date(2030, 1, 8, 0, 0, 0, 0, loc), wantStatus: http.StatusBadRequest},
+	}
+
+	for _, server := range serverZones {
+		for _, tt := range tests {
+			t.Run(server+"/"+tt.name, func(t *testing.T) {
+				withServerZone(t, server)
+				h.UseClock(clock.Fixed(tt.now))
+
+				slot, err := h.validateSlotTimes(context.Background(), venue, "2030-01-07", "18:00", "19:00")
+				if tt.wantStatus == 0 {
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if want := time.Date(2030, 1, 7, 11, 0, 0, 0, time.UTC); !slot.StartTime.Equal(want) {
+						t.Errorf("start is %s, want %s", slot.StartTime.UTC(), want)
+					}
+					return
+				}
+				serr, ok := err.(*slotError)
+				if !ok || serr.status != tt.wantStatus {
+					t.Errorf("got error %v, want status %d", err, tt.wantStatus)
+				}
+			})
+		}
+	}
+}
+
+// TestSendRemindersUsesClock ตรวจว่าอีเมลเตือนถูกส่งเฉพาะการจองที่เริ่มภายใน reminderLeadTime นับจากเวลาของ clock
+func TestSendRemindersUsesClock(t *testing.T) {
+	h, db := newTestHandler(t)
+	ctx := context.Background()
+
+	venue, court := newTestCourt(t, h, 1)
+	loc := mustLocation(t, venue.Timezone)
+	now := time.Date(2030, 1, 7, 17, 50, 0, 0, loc)
+	h.UseClock(clock.Fixed(now))
+	newTestUser(t, h, "65000001", rbac.RoleStudent)
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		wantSent bool
+	}{
+		{name: "already started", start: now.Add(-time.Minute)},
+		{name: "starting now", start: now},
+		{name: "within lead time", start: now.Add(10 * time.Minute), wantSent: true},
+		{name: "at lead time", start: now.Add(reminderLeadTime), wantSent: true},
+		{name: "after lead time", start: now.Add(reminderLeadTime + time.Minute)},
+	}
+
+	ids := make([]primitive.ObjectID, len(tests))
+	for i, tt := range tests {
+		booking := &models.Booking{
+			StudentID:   "65000001",
+			VenueID:     venue.ID,
+			CourtID:     court.ID,
+			CourtNumber: court.CourtNumber,
+			BookingDate: time.Date(2030, 1, 7, 0, 0, 0, 0, loc),
+			StartTime:   tt.start,
+			EndTime:     tt.start.Add(30 * time.Minute),
+			UserEmail:   "65000001@example.com",
+		}
+		booking.ID = primitive.NewObjectID()
+		if err := h.bookingRepo.Create(ctx, booking); err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = booking.ID
+	}
+
+	for _, server := range serverZones {
+		withServerZone(t, server)
+		h.SendReminders(ctx)
+	}
+
+	for i, tt := range tests {
+		var booking models.Booking
+		if err := db.Collection("bookings").FindOne(ctx, bson.M{"_id": ids[i]}).Decode(&booking); err != nil {
+			t.Fatal(err)
+		}
+		if booking.NotificationSent != tt.wantSent {
+			t.Errorf("%s: notification sent is %v, want %v", tt.name, booking.NotificationSent, tt.wantSent)
+		}
+	}
+
+	queued, err := db.Collection("outbox").CountDocuments(ctx, bson.M{"template": "reminder"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queued != 2 {
+		t.Errorf("got %d reminder emails in the outbox, want 2", queued)
+	}
+}
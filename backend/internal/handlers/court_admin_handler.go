@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/realtime"
+)
+
+// surfaceTypes are the court surfaces an admin can choose from
+var surfaceTypes = []string{"wood", "synthetic", "concrete"}
+
+// maxCourtCapacity จำกัดจำนวนผู้เล่นต่อคอร์ท
+const maxCourtCapacity = 20
+
+// validateCourtRequest ตรวจสอบรายละเอียดของคอร์ทที่แก้ไขได้
+func validateCourtRequest(req *models.CourtRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "Name is required"
+	}
+	if req.SurfaceType != "" && !slices.Contains(surfaceTypes, req.SurfaceType) {
+		return "surfaceType must be one of " + strings.Join(surfaceTypes, ", ")
+	}
+	if req.Capacity < 0 || req.Capacity > maxCourtCapacity {
+		return "capacity must be between 0 and 20"
+	}
+	return ""
+}
+
+// publishCourtStatus แจ้งหน้าจองที่เปิดอยู่ว่าคอร์ทเปิดหรือปิด
+func (h *Handler) publishCourtStatus(court *models.Court) {
+	isActive := court.IsActive
+	h.broker.Publish(realtime.Event{
+		Type:        realtime.EventCourtStatus,
+		VenueID:     court.VenueID.Hex(),
+		CourtNumber: court.CourtNumber,
+		IsActive:    &isActive,
+	})
+}
+
+// GetAdminCourts ดึงข้อมูลคอร์ทของสนามที่ดูแล ใช้ ?includeArchived=true เพื่อรวมคอร์ทที่เลิกใช้แล้ว
+// และ ?venueId= เพื่อดูเฉพาะสนามเดียว (ผู้ดูแลสนาม)
+func (h *Handler) GetAdminCourts(c *gin.Context) {
+	venueIDs, ok := adminVenueScope(c)
+	if !ok {
+		return
+	}
+
+	var courts []*models.Court
+	var err error
+	if c.Query("includeArchived") == "true" {
+		courts, err = h.courtRepo.FindAllWithArchived(c.Request.Context(), venueIDs)
+	} else {
+		courts, err = h.courtRepo.FindAll(c.Request.Context(), venueIDs)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch courts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, courts)
+}
+
+// CreateCourt เพิ่มคอร์ทใหม่ในสนาม เลขคอร์ทต้องไม่ซ้ำกับคอร์ทใดในสนามเดียวกัน (ผู้ดูแลสนาม)
+func (h *Handler) CreateCourt(c *gin.Context) {
+	var req models.CourtRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	venue, ok := h.findManagedVenue(c, req.VenueID)
+	if !ok {
+		return
+	}
+	if req.CourtNumber < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "courtNumber must be a positive number"})
+		return
+	}
+	if msg := validateCourtRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	court := &models.Court{
+		ID:          primitive.NewObjectID(),
+		VenueID:     venue.ID,
+		CourtNumber: req.CourtNumber,
+		Name:        req.Name,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		Location:    req.Location,
+		SurfaceType: req.SurfaceType,
+		Capacity:    req.Capacity,
+	}
+	err := h.courtRepo.Create(c.Request.Context(), court)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Court number is already in use at this venue"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create court"})
+		return
+	}
+
+	h.publishCourtStatus(court)
+	c.JSON(http.StatusCreated, court)
+}
+
+// UpdateCourt แก้ไขชื่อ ที่ตั้ง พื้นผิว และจำนวนผู้เล่นของคอร์ท สนามและเลขคอร์ทแก้ไขไม่ได้ (ผู้ดูแลสนาม)
+func (h *Handler) UpdateCourt(c *gin.Context) {
+	court, ok := h.findCourt(c)
+	if !ok {
+		return
+	}
+
+	var req models.CourtRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.CourtNumber != 0 && req.CourtNumber != court.CourtNumber {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Court number cannot be changed"})
+		return
+	}
+	if req.VenueID != "" && req.VenueID != court.VenueID.Hex() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Court venue cannot be changed"})
+		return
+	}
+	if msg := validateCourtRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	wasActive := court.IsActive
+	court.Name = req.Name
+	court.Location = req.Location
+	court.SurfaceType = req.SurfaceType
+	court.Capacity = req.Capacity
+	if req.IsActive != nil {
+		if *req.IsActive && court.ArchivedAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Restore the court before opening it"})
+			return
+		}
+		court.IsActive = *req.IsActive
+	}
+
+	if err := h.courtRepo.Update(c.Request.Context(), court); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update court"})
+		return
+	}
+
+	if court.IsActive != wasActive {
+		h.publishCourtStatus(court)
+	}
+	c.JSON(http.StatusOK, court)
+}
+
+// ArchiveCourt เลิกใช้คอร์ท (soft delete) คอร์ทยังอยู่ในฐานข้อมูลเพื่อให้การจองเก่าอ้างอิงได้
+// ถ้ายังมีการจองที่ยังไม่ถึงเวลา ต้องยกเลิกหรือย้ายการจองเหล่านั้นก่อน (ผู้ดูแลสนาม)
+func (h *Handler) ArchiveCourt(c *gin.Context) {
+	court, ok := h.findCourt(c)
+	if !ok {
+		return
+	}
+	if court.ArchivedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Court is already archived"})
+		return
+	}
+
+	// เลิกใช้คอร์ทก่อนแล้วค่อยตรวจการจอง การจองที่บันทึกพร้อมกันจะเห็นว่าคอร์ทเลิกใช้แล้วและถูกปฏิเสธ
+	// หรือถูกบันทึกก่อนและการตรวจด้านล่างจะเห็น จึงไม่มีการจองหลุดเข้ามาในคอร์ทที่เลิกใช้
+	archived, err := h.courtRepo.Archive(c.Request.Context(), court.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to archive court"})
+		return
+	}
+	if !archived {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Court is already archived"})
+		return
+	}
+
+	upcoming, err := h.bookingRepo.FindUpcomingByCourt(c.Request.Context(), court.VenueID, court.CourtNumber)
+	if err != nil {
+		h.undoArchive(c, court)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check court bookings"})
+		return
+	}
+	if len(upcoming) > 0 {
+		h.undoArchive(c, court)
+		loc, err := h.locationOf(c.Request.Context(), court.VenueID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check court bookings"})
+			return
+		}
+		bookings := make([]models.BookingResponse, 0, len(upcoming))
+		for _, booking := range upcoming {
+			bookings = append(bookings, toBookingResponse(booking, loc))
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    "Court has upcoming bookings, cancel them before archiving the court",
+			"bookings": bookings,
+		})
+		return
+	}
+
+	court.IsActive = false
+	h.publishCourtStatus(court)
+	c.JSON(http.StatusOK, gin.H{"message": "Court archived successfully"})
+}
+
+// undoArchive คืนสถานะคอร์ทที่เพิ่งเลิกใช้เมื่อเลิกใช้ไม่ได้ ความผิดพลาดถูก log ไว้เพราะ response ถูกกำหนดไว้แล้ว
+func (h *Handler) undoArchive(c *gin.Context, court *models.Court) {
+	if err := h.courtRepo.UndoArchive(c.Request.Context(), court.ID, court.IsActive); err != nil {
+		log.Printf("Error restoring court %s after a failed archive: %v", court.ID.Hex(), err)
+	}
+}
+
+// RestoreCourt นำคอร์ทที่เลิกใช้กลับมา คอร์ทจะยังปิดอยู่จนกว่าผู้ดูแลจะเปิด (ผู้ดูแลสนาม)
+func (h *Handler) RestoreCourt(c *gin.Context) {
+	court, ok := h.findCourt(c)
+	if !ok {
+		return
+	}
+
+	restored, err := h.courtRepo.Restore(c.Request.Context(), court.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore court"})
+		return
+	}
+	if !restored {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Court is not archived"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Court restored successfully"})
+}
+
+// findCourt ดึงคอร์ทจาก :id ใน URL และตอบ error ให้เองถ้าไม่พบหรือผู้ใช้ไม่ได้ดูแลสนามของคอร์ทนี้
+func (h *Handler) findCourt(c *gin.Context) (*models.Court, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid court ID"})
+		return nil, false
+	}
+
+	court, err := h.courtRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Court not found"})
+		return nil, false
+	}
+	if !requireVenueAccess(c, court.VenueID) {
+		return nil, false
+	}
+
+	return court, true
+}
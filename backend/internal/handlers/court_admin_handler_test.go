@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"courtopia-reserve/backend/internal/clock"
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/rbac"
+)
+
+// newArchiveTest เตรียมคอร์ทที่ไม่มีกฎการจองและตรึงเวลาไว้ที่ 2030-01-07 09:00 แล้วคืน router กับ token ของ admin
+func newArchiveTest(t *testing.T, h *Handler) (*models.Venue, *models.Court, *gin.Engine, string) {
+	t.Helper()
+	venue, court := newTestCourt(t, h, 1)
+	h.UseClock(clock.Fixed(time.Date(2030, 1, 7, 9, 0, 0, 0, mustLocation(t, venue.Timezone))))
+	if err := h.settingsRepo.UpdateBookingPolicy(context.Background(), &models.BookingPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	h.RegisterRoutes(router)
+	return venue, court, router, newTestUser(t, h, "admin001", rbac.RoleAdmin)
+}
+
+func serveJSON(router *gin.Engine, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestArchiveCourtWithUpcomingBooking ตรวจว่าการเลิกใช้คอร์ทที่ยังมีการจองถูกปฏิเสธ และคอร์ทกลับมาเปิดให้จองเหมือนเดิม
+func TestArchiveCourtWithUpcomingBooking(t *testing.T) {
+	h, _ := newTestHandler(t)
+	ctx := context.Background()
+	venue, court, router, adminToken := newArchiveTest(t, h)
+
+	token := newTestUser(t, h, "65000001", rbac.RoleStudent)
+	body := fmt.Sprintf(`{"venueId":%q,"courtNumber":1,"bookingDate":"2030-01-07","startTime":"18:00","endTime":"19:00"}`, venue.ID.Hex())
+	if rec := serveJSON(router, http.MethodPost, "/api/bookings", token, body); rec.Code != http.StatusCreated {
+		t.Fatalf("create booking: got status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := serveJSON(router, http.MethodDelete, "/api/admin/courts/"+court.ID.Hex(), adminToken, "")
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("archive: got status %d, want %d: %s", rec.Code, http.StatusConflict, rec.Body.String())
+	}
+
+	after, err := h.courtRepo.FindByID(ctx, court.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.ArchivedAt != nil || !after.IsActive {
+		t.Errorf("got archived at %v and active %v, want the court left open", after.ArchivedAt, after.IsActive)
+	}
+}
+
+// TestArchiveCourtConcurrentBookings เลิกใช้คอร์ทพร้อมกับที่นักศึกษาหลายคนจองคอร์ทนั้นคนละช่วงเวลา
+// ถ้าเลิกใช้สำเร็จต้องไม่มีการจองที่ active เหลืออยู่ในคอร์ท ถ้าไม่สำเร็จคอร์ทต้องกลับมาเปิดเหมือนเดิม
+func TestArchiveCourtConcurrentBookings(t *testing.T) {
+	h, db := newTestHandler(t)
+	ctx := context.Background()
+	venue, court, router, adminToken := newArchiveTest(t, h)
+
+	const n = 10
+	tokens := make([]string, n)
+	for i := range tokens {
+		tokens[i] = newTestUser(t, h, fmt.Sprintf("6500%04d", i), rbac.RoleStudent)
+	}
+
+	codes := make([]int, n)
+	var archiveCode int
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			body := fmt.Sprintf(`{"venueId":%q,"courtNumber":1,"bookingDate":"2030-01-07","startTime":"%02d:00","endTime":"%02d:00"}`, venue.ID.Hex(), 10+i, 11+i)
+			codes[i] = serveJSON(router, http.MethodPost, "/api/bookings", tokens[i], body).Code
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-start
+		archiveCode = serveJSON(router, http.MethodDelete, "/api/admin/courts/"+court.ID.Hex(), adminToken, "").Code
+	}()
+	close(start)
+	wg.Wait()
+
+	created := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusBadRequest:
+		default:
+			t.Errorf("got booking status %d, want 201 or 400 (codes %v)", code, codes)
+		}
+	}
+
+	active, err := db.Collection("bookings").CountDocuments(ctx, bson.M{"court_id": court.ID, "status": "active"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active != int64(created) {
+		t.Errorf("got %d active bookings, want %d", active, created)
+	}
+
+	after, err := h.courtRepo.FindByID(ctx, court.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch archiveCode {
+	case http.StatusOK:
+		if active != 0 {
+			t.Errorf("court was archived with %d active bookings", active)
+		}
+		if after.ArchivedAt == nil {
+			t.Error("archive succeeded but the court is not archived")
+		}
+	case http.StatusConflict:
+		if after.ArchivedAt != nil || !after.IsActive {
+			t.Errorf("got archived at %v and active %v after a refused archive, want the court left open", after.ArchivedAt, after.IsActive)
+		}
+	default:
+		t.Errorf("got archive status %d, want 200 or 409", archiveCode)
+	}
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -10,10 +9,19 @@ import (
 	"courtopia-reserve/backend/internal/models"
 )
 
-// GetCourts ดึงข้อมูลคอร์ททั้งหมด
+// GetCourts ดึงข้อมูลคอร์ททั้งหมด หรือเฉพาะของสนามที่ระบุ
 func (h *Handler) GetCourts(c *gin.Context) {
-	// ดึงข้อมูลคอร์ททั้งหมดจาก repository
-	courts, err := h.courtRepo.FindAll(c.Request.Context())
+	var venueIDs []primitive.ObjectID
+	if idStr := venueParam(c); idStr != "" {
+		venue, ok := h.findVenue(c, idStr)
+		if !ok {
+			return
+		}
+		venueIDs = []primitive.ObjectID{venue.ID}
+	}
+
+	// ดึงข้อมูลคอร์ทจาก repository
+	courts, err := h.courtRepo.FindAll(c.Request.Context(), venueIDs)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch courts"})
 		return
@@ -44,8 +52,13 @@ func (h *Handler) GetCourt(c *gin.Context) {
 	c.JSON(http.StatusOK, court)
 }
 
-// GetAvailableCourts ดึงข้อมูลคอร์ทที่ว่างในช่วงเวลาที่กำหนด
+// GetAvailableCourts ดึงข้อมูลคอร์ทของสนามที่ว่างในช่วงเวลาที่กำหนด
 func (h *Handler) GetAvailableCourts(c *gin.Context) {
+	venue, ok := h.findVenue(c, venueParam(c))
+	if !ok {
+		return
+	}
+
 	// รับ parameters จาก query string
 	dateStr := c.Query("date")
 	startTimeStr := c.Query("startTime")
@@ -58,52 +71,27 @@ func (h *Handler) GetAvailableCourts(c *gin.Context) {
 	}
 
 	// แปลงวันที่และเวลาให้อยู่ในรูปแบบที่ถูกต้อง
-	bookingDate, err := time.Parse("2006-01-02", dateStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format, use YYYY-MM-DD"})
+	bookingDate, startTime, endTime, perr := parseSlotTimes(h.venueLocation(venue), dateStr, startTimeStr, endTimeStr)
+	if perr != nil {
+		respondSlotError(c, perr)
 		return
 	}
 
-	// สร้างเวลาเริ่มต้นและสิ้นสุดโดยรวมกับวันที่
-	layout := "15:04"
-	startTimeParsed, err := time.Parse(layout, startTimeStr)
+	// ตรวจสอบว่าช่วงเวลาอยู่ในเวลาทำการของสนาม
+	hours, err := h.settingsRepo.GetOperatingHours(c.Request.Context(), venue.ID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start time format, use HH:MM"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load operating hours"})
 		return
 	}
-
-	endTimeParsed, err := time.Parse(layout, endTimeStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end time format, use HH:MM"})
+	if herr := checkOperatingHours(hours, startTime, endTime); herr != nil {
+		respondSlotError(c, herr)
 		return
 	}
 
-	// สร้าง datetime objects สำหรับช่วงเวลาที่ต้องการจอง
-	startTime := time.Date(
-		bookingDate.Year(),
-		bookingDate.Month(),
-		bookingDate.Day(),
-		startTimeParsed.Hour(),
-		startTimeParsed.Minute(),
-		0,
-		0,
-		bookingDate.Location(),
-	)
-
-	endTime := time.Date(
-		bookingDate.Year(),
-		bookingDate.Month(),
-		bookingDate.Day(),
-		endTimeParsed.Hour(),
-		endTimeParsed.Minute(),
-		0,
-		0,
-		bookingDate.Location(),
-	)
-
 	// ตรวจสอบคอร์ทที่ว่าง
 	availabilities, err := h.bookingRepo.GetAvailableCourts(
 		c.Request.Context(),
+		venue.ID,
 		bookingDate,
 		startTime,
 		endTime,
@@ -116,6 +104,7 @@ func (h *Handler) GetAvailableCourts(c *gin.Context) {
 
 	// สร้างข้อมูล response
 	response := models.AvailabilityResponse{
+		VenueID:     venue.ID.Hex(),
 		BookingDate: dateStr,
 		StartTime:   startTimeStr,
 		EndTime:     endTimeStr,
@@ -126,7 +115,7 @@ func (h *Handler) GetAvailableCourts(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// UpdateCourtStatus อัปเดตสถานะคอร์ท (สำหรับ admin)
+// UpdateCourtStatus อัปเดตสถานะคอร์ท (สำหรับผู้ดูแลสนาม)
 func (h *Handler) UpdateCourtStatus(c *gin.Context) {
 	// ดึงค่า ID จาก URL
 	idStr := c.Param("id")
@@ -146,12 +135,30 @@ func (h *Handler) UpdateCourtStatus(c *gin.Context) {
 		return
 	}
 
+	// คอร์ทที่เลิกใช้แล้วต้องนำกลับมาก่อนจึงจะเปิดได้
+	court, err := h.courtRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Court not found"})
+		return
+	}
+	if !requireVenueAccess(c, court.VenueID) {
+		return
+	}
+	if req.IsActive && court.ArchivedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Restore the court before opening it"})
+		return
+	}
+
 	// อัปเดตสถานะคอร์ท
 	if err := h.courtRepo.UpdateStatus(c.Request.Context(), id, req.IsActive); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update court status"})
 		return
 	}
 
+	// แจ้งหน้าจองที่เปิดอยู่ทุกวันที่ ว่าคอร์ทนี้เปิดหรือปิด
+	court.IsActive = req.IsActive
+	h.publishCourtStatus(court)
+
 	// ส่ง response กลับไป
 	c.JSON(http.StatusOK, gin.H{"message": "Court status updated successfully"})
 }
@@ -1,23 +1,48 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"courtopia-reserve/backend/internal/clock"
+	"courtopia-reserve/backend/internal/config"
+	"courtopia-reserve/backend/internal/notify"
+	"courtopia-reserve/backend/internal/rbac"
+	"courtopia-reserve/backend/internal/realtime"
 	"courtopia-reserve/backend/internal/repository"
+	"courtopia-reserve/backend/internal/webhook"
 	"courtopia-reserve/backend/pkg/utils"
 )
 
 // Handler holds the database client and other dependencies
 type Handler struct {
-	db          *mongo.Database
-	userRepo    *repository.UserRepository
-	courtRepo   *repository.CourtRepository
-	bookingRepo *repository.BookingRepository
-	jwtSecret   string
+	db              *mongo.Database
+	userRepo        *repository.UserRepository
+	courtRepo       *repository.CourtRepository
+	venueRepo       *repository.VenueRepository
+	bookingRepo     *repository.BookingRepository
+	settingsRepo    *repository.SettingsRepository
+	blackoutRepo    *repository.BlackoutRepository
+	seriesRepo      *repository.SeriesRepository
+	waitlistRepo    *repository.WaitlistRepository
+	outboxRepo      *repository.OutboxRepository
+	webhookRepo     *repository.WebhookRepository
+	deliveryRepo    *repository.WebhookDeliveryRepository
+	webhookSender   *webhook.Sender
+	broker          *realtime.Broker
+	sessionRepo     *repository.SessionRepository
+	actionTokenRepo *repository.ActionTokenRepository
+	loginRepo       *repository.LoginRepository
+	notifier        notify.Notifier
+	cfg             *config.Config
+	jwtSecret       string
+	clock           clock.Clock    // เวลาปัจจุบัน เปลี่ยนเป็น clock.Fixed ได้ตอนทดสอบ
+	location        *time.Location // timezone จาก config ใช้กับสนามที่ไม่ได้กำหนด timezone
 }
 
 // NewHandler creates a new handler instance
@@ -26,17 +51,65 @@ func NewHandler(
 	userRepo *repository.UserRepository,
 	courtRepo *repository.CourtRepository,
 	bookingRepo *repository.BookingRepository,
-	jwtSecret string,
+	notifier notify.Notifier,
+	broker *realtime.Broker,
+	cfg *config.Config,
 ) *Handler {
+	location, err := clock.LoadLocation(cfg.Timezone)
+	if err != nil {
+		location, _ = clock.LoadLocation(clock.DefaultTimezone)
+	}
+
 	return &Handler{
-		db:          db,
-		userRepo:    userRepo,
-		courtRepo:   courtRepo,
-		bookingRepo: bookingRepo,
-		jwtSecret:   jwtSecret,
+		db:              db,
+		userRepo:        userRepo,
+		courtRepo:       courtRepo,
+		venueRepo:       repository.NewVenueRepository(db),
+		bookingRepo:     bookingRepo,
+		settingsRepo:    repository.NewSettingsRepository(db),
+		blackoutRepo:    repository.NewBlackoutRepository(db),
+		seriesRepo:      repository.NewSeriesRepository(db),
+		waitlistRepo:    repository.NewWaitlistRepository(db),
+		outboxRepo:      repository.NewOutboxRepository(db),
+		webhookRepo:     repository.NewWebhookRepository(db),
+		deliveryRepo:    repository.NewWebhookDeliveryRepository(db),
+		webhookSender:   webhook.NewSender(10 * time.Second),
+		broker:          broker,
+		sessionRepo:     repository.NewSessionRepository(db),
+		actionTokenRepo: repository.NewActionTokenRepository(db),
+		loginRepo:       repository.NewLoginRepository(db),
+		notifier:        notifier,
+		cfg:             cfg,
+		jwtSecret:       cfg.JWTSecret,
+		clock:           clock.System{},
+		location:        location,
 	}
 }
 
+// UseClock makes the handler and all of its repositories read the current time from c.
+// Tests use it with clock.Fixed to pin "now".
+func (h *Handler) UseClock(c clock.Clock) {
+	h.clock = c
+	for _, repo := range []interface{ UseClock(clock.Clock) }{
+		h.userRepo, h.courtRepo, h.venueRepo, h.bookingRepo, h.settingsRepo, h.blackoutRepo,
+		h.seriesRepo, h.waitlistRepo, h.outboxRepo, h.webhookRepo, h.deliveryRepo,
+		h.sessionRepo, h.actionTokenRepo, h.loginRepo,
+	} {
+		repo.UseClock(c)
+	}
+}
+
+// EnsureIndexes creates the indexes of the repositories the handler owns
+func (h *Handler) EnsureIndexes(ctx context.Context) error {
+	if err := h.sessionRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	if err := h.actionTokenRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	return h.loginRepo.EnsureIndexes(ctx)
+}
+
 // AuthMiddleware returns a middleware to verify JWT tokens
 func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -64,14 +137,28 @@ func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// ตรวจว่า session หรือ token นี้ยังไม่ถูก logout
+		valid, err := h.checkTokenRevocation(c.Request.Context(), claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify token"})
+			c.Abort()
+			return
+		}
+		if !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.Abort()
+			return
+		}
+
 		// เพิ่มข้อมูล user เข้าไปใน context
 		c.Set("user", claims)
 		c.Next()
 	}
 }
 
-// AdminMiddleware returns middleware to check admin privileges
-func (h *Handler) AdminMiddleware() gin.HandlerFunc {
+// RequirePermission returns middleware that only lets through users whose role grants every given permission.
+// It must run after AuthMiddleware.
+func (h *Handler) RequirePermission(perms ...rbac.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// ดึงข้อมูล user จาก context
 		claims, exists := c.Get("user")
@@ -81,9 +168,9 @@ func (h *Handler) AdminMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// ตรวจสอบว่าเป็น admin หรือไม่
-		if claims.(*utils.Claims).Role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		// ตรวจสอบว่า role ของผู้ใช้มีสิทธิ์ที่ต้องการครบหรือไม่
+		if !rbac.Can(claims.(*utils.Claims).Role, perms...) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
 			c.Abort()
 			return
 		}
@@ -101,13 +188,33 @@ func (h *Handler) RegisterRoutes(r *gin.Engine) {
 	{
 		auth.POST("/register", h.Register)
 		auth.POST("/login", h.Login)
+		auth.POST("/refresh", h.RefreshToken)
+		auth.POST("/logout", h.AuthMiddleware(), h.Logout)
+		auth.POST("/logout-all", h.AuthMiddleware(), h.LogoutAll)
+		auth.POST("/verify-email", h.VerifyEmail)
+		auth.POST("/verify-email/resend", h.AuthMiddleware(), h.ResendVerificationEmail)
+		auth.POST("/forgot-password", h.ForgotPassword)
+		auth.POST("/reset-password", h.ResetPassword)
 	}
 
 	// Public court routes
+	// สนาม: /venues/:venueId/courts/... เท่ากับ /courts/...?venueId=
+	venues := api.Group("/venues")
+	{
+		venues.GET("", h.GetVenues)
+		venues.GET("/:venueId", h.GetVenue)
+		venues.GET("/:venueId/courts", h.GetCourts)
+		venues.GET("/:venueId/courts/available", h.GetAvailableCourts)
+		venues.GET("/:venueId/courts/schedule", h.GetCourtSchedule)
+		venues.GET("/:venueId/courts/stream", h.StreamCourtAvailability)
+	}
+
 	courts := api.Group("/courts")
 	{
 		courts.GET("", h.GetCourts)
 		courts.GET("/available", h.GetAvailableCourts)
+		courts.GET("/schedule", h.GetCourtSchedule)
+		courts.GET("/stream", h.StreamCourtAvailability)
 		courts.GET("/:id", h.GetCourt)
 	}
 
@@ -118,22 +225,74 @@ func (h *Handler) RegisterRoutes(r *gin.Engine) {
 		bookings.POST("", h.CreateBooking)
 		bookings.GET("", h.GetUserBookings)
 		bookings.POST("/check", h.CheckAvailability)
+		bookings.PATCH("/:id", h.RescheduleBooking)
 		bookings.DELETE("/:id", h.CancelBooking)
+		bookings.GET("/:id/ics", h.DownloadBookingICS)
+		bookings.POST("/:id/checkin", h.CheckInBooking)
+		bookings.POST("/series", h.CreateSeries)
+		bookings.GET("/series/:id", h.GetSeries)
+		bookings.POST("/series/:id/cancel", h.CancelSeries)
+	}
+
+	waitlist := api.Group("/waitlist")
+	waitlist.Use(h.AuthMiddleware())
+	{
+		waitlist.POST("", h.JoinWaitlist)
+		waitlist.GET("", h.GetWaitlist)
+		waitlist.DELETE("/:id", h.LeaveWaitlist)
 	}
 
 	profile := api.Group("/profile")
 	profile.Use(h.AuthMiddleware())
 	{
-		profile.GET("", h.GetProfile)                   // ดึงข้อมูลโปรไฟล์
-		profile.PUT("", h.UpdateProfile)                // อัปเดตข้อมูลโปรไฟล์
-		profile.POST("/upload", h.UploadProfilePicture) // อัปโหลดรูปโปรไฟล์
+		profile.GET("", h.GetProfile)                        // ดึงข้อมูลโปรไฟล์
+		profile.PUT("", h.UpdateProfile)                     // อัปเดตข้อมูลโปรไฟล์
+		profile.POST("/upload", h.UploadProfilePicture)      // อัปโหลดรูปโปรไฟล์
+		profile.GET("/calendar", h.GetCalendarFeed)          // URL ของ calendar feed
+		profile.POST("/calendar/reset", h.ResetCalendarFeed) // เปลี่ยน URL ของ calendar feed
 	}
 
-	// Admin routes
+	// Calendar feed ยืนยันตัวตนด้วย token ใน URL
+	api.GET("/calendar/:token", h.CalendarFeed)
+
+	// Admin routes แต่ละ route ต้องการสิทธิ์ตามหน้าที่ ไม่ใช่เฉพาะ role admin
 	admin := api.Group("/admin")
-	admin.Use(h.AuthMiddleware(), h.AdminMiddleware())
+	admin.Use(h.AuthMiddleware())
 	{
-		admin.PATCH("/courts/:id/status", h.UpdateCourtStatus)
-		admin.GET("/bookings", h.GetAllBookings)
+		admin.GET("/courts", h.RequirePermission(rbac.CourtsManage), h.GetAdminCourts)
+		admin.POST("/courts", h.RequirePermission(rbac.CourtsManage), h.CreateCourt)
+		admin.PUT("/courts/:id", h.RequirePermission(rbac.CourtsManage), h.UpdateCourt)
+		admin.DELETE("/courts/:id", h.RequirePermission(rbac.CourtsManage), h.ArchiveCourt)
+		admin.POST("/courts/:id/restore", h.RequirePermission(rbac.CourtsManage), h.RestoreCourt)
+		admin.PATCH("/courts/:id/status", h.RequirePermission(rbac.CourtsOperate), h.UpdateCourtStatus)
+		admin.GET("/courts/:id/checkin-code", h.RequirePermission(rbac.CourtsOperate), h.GetCourtCheckInCode)
+		admin.GET("/bookings", h.RequirePermission(rbac.BookingsReadAll), h.GetAllBookings)
+		admin.GET("/venues", h.RequirePermission(rbac.VenuesManage), h.GetAdminVenues)
+		admin.POST("/venues", h.RequirePermission(rbac.VenuesCreate), h.CreateVenue)
+		admin.PUT("/venues/:venueId", h.RequirePermission(rbac.VenuesManage), h.UpdateVenue)
+		admin.GET("/venues/:venueId/operating-hours", h.RequirePermission(rbac.VenuesManage), h.GetOperatingHours)
+		admin.PUT("/venues/:venueId/operating-hours", h.RequirePermission(rbac.VenuesManage), h.UpdateOperatingHours)
+		admin.GET("/operating-hours", h.RequirePermission(rbac.VenuesManage), h.GetOperatingHours)
+		admin.PUT("/operating-hours", h.RequirePermission(rbac.VenuesManage), h.UpdateOperatingHours)
+		admin.GET("/booking-policy", h.RequirePermission(rbac.SettingsManage), h.GetBookingPolicy)
+		admin.PUT("/booking-policy", h.RequirePermission(rbac.SettingsManage), h.UpdateBookingPolicy)
+		admin.GET("/blackouts", h.RequirePermission(rbac.VenuesManage), h.GetBlackouts)
+		admin.POST("/blackouts", h.RequirePermission(rbac.VenuesManage), h.CreateBlackout)
+		admin.DELETE("/blackouts/:id", h.RequirePermission(rbac.VenuesManage), h.DeleteBlackout)
+		admin.GET("/notifications", h.RequirePermission(rbac.NotificationsManage), h.GetNotifications)
+		admin.POST("/notifications/:id/retry", h.RequirePermission(rbac.NotificationsManage), h.RetryNotification)
+		admin.GET("/webhooks", h.RequirePermission(rbac.WebhooksManage), h.GetWebhooks)
+		admin.POST("/webhooks", h.RequirePermission(rbac.WebhooksManage), h.CreateWebhook)
+		admin.PUT("/webhooks/:id", h.RequirePermission(rbac.WebhooksManage), h.UpdateWebhook)
+		admin.DELETE("/webhooks/:id", h.RequirePermission(rbac.WebhooksManage), h.DeleteWebhook)
+		admin.POST("/webhooks/:id/test", h.RequirePermission(rbac.WebhooksManage), h.TestWebhook)
+		admin.GET("/webhooks/:id/deliveries", h.RequirePermission(rbac.WebhooksManage), h.GetWebhookDeliveries)
+		admin.POST("/webhooks/:id/deliveries/:deliveryId/retry", h.RequirePermission(rbac.WebhooksManage), h.RetryWebhookDelivery)
+		admin.GET("/login-lockouts", h.RequirePermission(rbac.UsersManage), h.GetLoginLockouts)
+		admin.DELETE("/login-lockouts/:id", h.RequirePermission(rbac.UsersManage), h.ClearLoginLockout)
+		admin.GET("/login-attempts", h.RequirePermission(rbac.UsersManage), h.GetLoginAttempts)
+		admin.GET("/roles", h.RequirePermission(rbac.UsersManage), h.GetRoles)
+		admin.GET("/users", h.RequirePermission(rbac.UsersManage), h.GetUsers)
+		admin.PUT("/users/:studentId/role", h.RequirePermission(rbac.UsersManage), h.UpdateUserRole)
 	}
 }
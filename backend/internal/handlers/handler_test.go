@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"courtopia-reserve/backend/internal/clock"
+	"courtopia-reserve/backend/internal/config"
+	"courtopia-reserve/backend/internal/database"
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/realtime"
+	"courtopia-reserve/backend/internal/repository"
+	"courtopia-reserve/backend/pkg/utils"
+)
+
+const testJWTSecret = "test-secret"
+
+// newTestHandler สร้าง Handler ที่ใช้ฐานข้อมูลทดสอบแยกต่างหาก ซึ่งถูกลบเมื่อจบการทดสอบ
+// ต้องกำหนด TEST_MONGO_URI ไม่เช่นนั้นการทดสอบจะถูกข้าม
+func newTestHandler(t *testing.T) (*Handler, *mongo.Database) {
+	t.Helper()
+
+	uri := os.Getenv("TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("TEST_MONGO_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect to MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("ping MongoDB: %v", err)
+	}
+
+	db := client.Database("courtopia_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		_ = db.Drop(context.Background())
+		_ = client.Disconnect(context.Background())
+	})
+
+	cfg := &config.Config{JWTSecret: testJWTSecret, Timezone: clock.DefaultTimezone, AccessTokenTTL: time.Hour}
+	bookingRepo := repository.NewBookingRepository(db)
+	courtRepo := repository.NewCourtRepository(db)
+	if err := bookingRepo.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("create booking indexes: %v", err)
+	}
+	if err := courtRepo.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("create court indexes: %v", err)
+	}
+	bookingRepo.UseTransactions(database.SupportsTransactions(ctx, client))
+
+	gin.SetMode(gin.TestMode)
+	h := NewHandler(db, repository.NewUserRepository(db), courtRepo, bookingRepo, nil, realtime.NewBroker(), cfg)
+	return h, db
+}
+
+// newTestUser สร้างผู้ใช้ที่ยืนยันอีเมลแล้วพร้อม session และคืน access token ของผู้ใช้
+// เวลาของ session คิดจาก clock ของ Handler เพราะ session repository ตรวจอายุ session ด้วย clock เดียวกัน
+func newTestUser(t *testing.T, h *Handler, studentID string, role string) string {
+	t.Helper()
+	ctx := context.Background()
+
+	verifiedAt := h.clock.Now()
+	user := &models.User{
+		ID:              primitive.NewObjectID(),
+		StudentID:       studentID,
+		Name:            "Student " + studentID,
+		Email:           studentID + "@example.com",
+		Role:            role,
+		EmailVerifiedAt: &verifiedAt,
+	}
+	if err := h.userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	session := &models.Session{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		StudentID: user.StudentID,
+		ExpiresAt: h.clock.Now().Add(time.Hour),
+	}
+	if err := h.sessionRepo.Create(ctx, session); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	token, err := utils.GenerateToken(user, testJWTSecret, session.ID.Hex(), time.Hour)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	return token
+}
+
+// newTestCourt สร้างสนามและคอร์ทที่เปิดให้จอง
+func newTestCourt(t *testing.T, h *Handler, courtNumber int) (*models.Venue, *models.Court) {
+	t.Helper()
+	ctx := context.Background()
+
+	venue := &models.Venue{Name: "Test Hall", Timezone: clock.DefaultTimezone}
+	if err := h.venueRepo.Create(ctx, venue); err != nil {
+		t.Fatalf("create venue: %v", err)
+	}
+
+	court := &models.Court{
+		ID:          primitive.NewObjectID(),
+		VenueID:     venue.ID,
+		CourtNumber: courtNumber,
+		Name:        "Court",
+		IsActive:    true,
+	}
+	if err := h.courtRepo.Create(ctx, court); err != nil {
+		t.Fatalf("create court: %v", err)
+	}
+	return venue, court
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/repository"
+)
+
+// Login attempt outcomes recorded in the audit trail
+const (
+	loginSuccess         = "success"
+	loginUnknownUser     = "unknown_user"
+	loginInvalidPassword = "invalid_password"
+	loginThrottled       = "throttled"
+	loginLocked          = "locked"
+)
+
+// checkLoginThrottle ตรวจว่าบัญชีหรือ IP นี้ยังต้องรอก่อน login ได้อีกหรือไม่
+// ตรวจก่อนเทียบรหัสผ่าน เพื่อไม่ให้การเดารหัสผ่านกินเวลา bcrypt ของ server
+func (h *Handler) checkLoginThrottle(ctx context.Context, studentID string, ip string) (time.Time, string, error) {
+	var retryAt time.Time
+	reason := ""
+
+	keys := []struct{ kind, subject string }{
+		{repository.LoginThrottleAccount, studentID},
+		{repository.LoginThrottleIP, ip},
+	}
+	for _, key := range keys {
+		throttle, err := h.loginRepo.FindThrottle(ctx, key.kind, key.subject)
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		if throttle == nil {
+			continue
+		}
+
+		at, blocked := repository.LoginRetryAt(throttle, h.clock.Now())
+		if !blocked || !at.After(retryAt) {
+			continue
+		}
+		retryAt = at
+		reason = loginThrottled
+		if throttle.LockedUntil != nil && !throttle.LockedUntil.Before(at) {
+			reason = loginLocked
+		}
+	}
+
+	return retryAt, reason, nil
+}
+
+// rejectThrottledLogin ตอบ 429 พร้อมบอกว่าต้องรออีกกี่วินาที
+func (h *Handler) rejectThrottledLogin(c *gin.Context, retryAt time.Time) {
+	seconds := int(math.Ceil(retryAt.Sub(h.clock.Now()).Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":      "เข้าสู่ระบบผิดหลายครั้งเกินไป กรุณาลองใหม่ภายหลัง",
+		"retryAfter": seconds,
+	})
+}
+
+// recordLoginFailure นับความผิดพลาดทั้งของบัญชีและของ IP แล้วบันทึกลงประวัติการ login
+// นับด้วยรหัสนักศึกษาแม้ไม่มีบัญชีนั้น เพื่อไม่ให้ใช้ความต่างของ response ตรวจว่ามีบัญชีอยู่หรือไม่
+func (h *Handler) recordLoginFailure(c *gin.Context, studentID string, user *models.User, reason string) {
+	ctx := c.Request.Context()
+
+	if _, err := h.loginRepo.RecordFailure(ctx, repository.LoginThrottleAccount, studentID, repository.AccountLoginLimits); err != nil {
+		log.Printf("Error recording failed login for %s: %v", studentID, err)
+	}
+	if _, err := h.loginRepo.RecordFailure(ctx, repository.LoginThrottleIP, c.ClientIP(), repository.IPLoginLimits); err != nil {
+		log.Printf("Error recording failed login from %s: %v", c.ClientIP(), err)
+	}
+
+	h.recordLoginAttempt(c, studentID, user, reason)
+}
+
+// recordLoginAttempt บันทึกความพยายาม login หนึ่งครั้งลงประวัติ
+func (h *Handler) recordLoginAttempt(c *gin.Context, studentID string, user *models.User, reason string) {
+	attempt := &models.LoginAttempt{
+		StudentID: studentID,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Success:   reason == loginSuccess,
+		Reason:    reason,
+	}
+	if user != nil {
+		attempt.UserID = &user.ID
+	}
+
+	if err := h.loginRepo.RecordAttempt(c.Request.Context(), attempt); err != nil {
+		log.Printf("Error recording login attempt for %s: %v", studentID, err)
+	}
+}
+
+// GetLoginLockouts แสดงบัญชีและ IP ที่ login ผิดเมื่อเร็วๆ นี้ (admin only)
+// ใช้ ?locked=true เพื่อดูเฉพาะที่ถูกล็อกอยู่
+func (h *Handler) GetLoginLockouts(c *gin.Context) {
+	kind := c.Query("kind")
+	switch kind {
+	case "", repository.LoginThrottleAccount, repository.LoginThrottleIP:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be account or ip"})
+		return
+	}
+
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		limit = n
+	}
+
+	throttles, err := h.loginRepo.FindThrottles(c.Request.Context(), kind, c.Query("locked") == "true", limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lockouts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, throttles)
+}
+
+// ClearLoginLockout ปลดล็อกบัญชีหรือ IP และล้างจำนวนครั้งที่ login ผิด (admin only)
+func (h *Handler) ClearLoginLockout(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lockout ID"})
+		return
+	}
+
+	deleted, err := h.loginRepo.DeleteThrottle(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear lockout"})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lockout not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Lockout cleared"})
+}
+
+// GetLoginAttempts แสดงประวัติการ login กรองด้วย studentId, ip และ success ได้ (admin only)
+func (h *Handler) GetLoginAttempts(c *gin.Context) {
+	var success *bool
+	if raw := c.Query("success"); raw != "" {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "success must be true or false"})
+			return
+		}
+		success = &value
+	}
+
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		limit = n
+	}
+
+	attempts, err := h.loginRepo.FindAttempts(c.Request.Context(), c.Query("studentId"), c.Query("ip"), success, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch login attempts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"courtopia-reserve/backend/internal/calendar"
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/notify"
+)
+
+// errNoEmail is recorded on outbox messages for users without an email address
+var errNoEmail = errors.New("user has no email address")
+
+// errNoLink is recorded on account emails whose link was already cleared, for example
+// when a dead message is retried; the user has to request a new link
+var errNoLink = errors.New("link is no longer available, a new one must be requested")
+
+// sendBookingEmail ส่งอีเมลเกี่ยวกับการจองด้วย template ในภาษาที่ผู้ใช้เลือก
+func (h *Handler) sendBookingEmail(ctx context.Context, user *models.User, template string, booking *models.Booking) error {
+	if user.Email == "" {
+		return errNoEmail
+	}
+
+	venue, err := h.venueRepo.FindByID(ctx, booking.VenueID)
+	if err != nil {
+		return err
+	}
+
+	// วันและเวลาในอีเมลเป็นเวลาท้องถิ่นของสนาม
+	loc := h.venueLocation(venue)
+	data := notify.Data{
+		Name:        user.Name,
+		Venue:       venue.Name,
+		CourtNumber: booking.CourtNumber,
+		Date:        booking.BookingDate.In(loc).Format("2006-01-02"),
+		StartTime:   booking.StartTime.In(loc).Format("15:04"),
+		EndTime:     booking.EndTime.In(loc).Format("15:04"),
+	}
+	// อีเมลแจ้งการย้ายการจองบอกคอร์ทและเวลาก่อนการย้ายครั้งล่าสุดด้วย
+	if template == notify.TemplateBookingChange && len(booking.Changes) > 0 {
+		from := booking.Changes[len(booking.Changes)-1].From
+		data.PreviousCourtNumber = from.CourtNumber
+		data.PreviousDate = from.BookingDate.In(loc).Format("2006-01-02")
+		data.PreviousStartTime = from.StartTime.In(loc).Format("15:04")
+		data.PreviousEndTime = from.EndTime.In(loc).Format("15:04")
+	}
+	if template == notify.TemplateBookingCancellation && booking.Cancellation != nil {
+		data.Reason = booking.Cancellation.Reason
+	}
+
+	msg, err := notify.Render(user.Language, template, user.Email, data)
+	if err != nil {
+		return err
+	}
+
+	// แนบไฟล์ .ics ไปกับอีเมลที่ยืนยันว่าได้คอร์ท เพื่อให้เพิ่มลงปฏิทินได้ทันที
+	// การจองที่ถูกย้ายใช้ UID เดิม ปฏิทินจึงย้าย event เดิมให้
+	if template == notify.TemplateBookingConfirmation || template == notify.TemplateWaitlistPromotion || template == notify.TemplateBookingChange {
+		msg.Attachments = append(msg.Attachments, notify.Attachment{
+			Filename:    bookingICSFilename(booking),
+			ContentType: calendar.ContentType + "; method=PUBLISH",
+			Data:        bookingICS(booking, venue),
+		})
+	}
+
+	return h.notifier.Send(ctx, msg)
+}
+
+// sendAccountEmail ส่งอีเมลเกี่ยวกับบัญชี เช่น ลิงก์ยืนยันอีเมล ไปยังอีเมลที่ token ถูกออกให้
+func (h *Handler) sendAccountEmail(ctx context.Context, user *models.User, msg *models.OutboxMessage) error {
+	link := msg.Data["link"]
+	if link == "" {
+		return errNoLink
+	}
+	to := msg.Data["email"]
+	if to == "" {
+		return errNoEmail
+	}
+	expiresHours, _ := strconv.Atoi(msg.Data["expires_hours"])
+
+	email, err := notify.Render(user.Language, msg.Template, to, notify.Data{
+		Name:         user.Name,
+		Link:         link,
+		ExpiresHours: expiresHours,
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.notifier.Send(ctx, email)
+}
+
+// DeliverOutbox ส่งข้อความใน outbox ที่ถึงเวลาส่งจนกว่าจะหมดคิว
+// ข้อความที่ส่งไม่สำเร็จจะถูก retry แบบ exponential backoff และถูกย้ายไปสถานะ dead เมื่อครบจำนวนครั้ง
+func (h *Handler) DeliverOutbox(ctx context.Context) {
+	for {
+		msg, err := h.outboxRepo.ClaimDue(ctx)
+		if err != nil {
+			log.Printf("Error claiming outbox message: %v", err)
+			return
+		}
+		if msg == nil {
+			return
+		}
+
+		permanent, err := h.deliverOutboxMessage(ctx, msg)
+		if err == nil {
+			if err := h.outboxRepo.MarkSent(ctx, msg.ID); err != nil {
+				log.Printf("Error marking outbox message %s as sent: %v", msg.ID.Hex(), err)
+			}
+			continue
+		}
+
+		log.Printf("Error delivering %s for booking %s (attempt %d): %v", msg.Template, msg.BookingID.Hex(), msg.Attempts+1, err)
+		if err := h.outboxRepo.MarkFailed(ctx, msg, err, permanent); err != nil {
+			log.Printf("Error recording failed outbox message %s: %v", msg.ID.Hex(), err)
+		}
+	}
+}
+
+// deliverOutboxMessage ส่งข้อความหนึ่งรายการ และบอกว่าความผิดพลาดเป็นแบบถาวรหรือไม่
+// (ไม่มีผู้ใช้ ไม่มีการจอง ไม่มีอีเมล หรือไม่มีลิงก์แล้ว ซึ่ง retry ไปก็ไม่สำเร็จ)
+func (h *Handler) deliverOutboxMessage(ctx context.Context, msg *models.OutboxMessage) (bool, error) {
+	user, err := h.userRepo.FindByStudentID(ctx, msg.StudentID)
+	if err != nil {
+		return errors.Is(err, mongo.ErrNoDocuments), err
+	}
+
+	// อีเมลเกี่ยวกับบัญชีไม่ได้ผูกกับการจอง
+	if msg.BookingID.IsZero() {
+		err := h.sendAccountEmail(ctx, user, msg)
+		return errors.Is(err, errNoEmail) || errors.Is(err, errNoLink), err
+	}
+
+	booking, err := h.bookingRepo.FindByID(ctx, msg.BookingID)
+	if err != nil {
+		return errors.Is(err, mongo.ErrNoDocuments), err
+	}
+
+	err = h.sendBookingEmail(ctx, user, msg.Template, booking)
+	return errors.Is(err, errNoEmail), err
+}
+
+// GetNotifications แสดงประวัติการส่งอีเมลสำหรับเจ้าหน้าที่ (admin only)
+// กรองได้ด้วย studentId, bookingId และ status
+func (h *Handler) GetNotifications(c *gin.Context) {
+	var bookingID *primitive.ObjectID
+	if raw := c.Query("bookingId"); raw != "" {
+		id, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+			return
+		}
+		bookingID = &id
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", "pending", "sending", "sent", "dead":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		limit = n
+	}
+
+	messages, err := h.outboxRepo.FindAll(c.Request.Context(), c.Query("studentId"), bookingID, status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// RetryNotification นำข้อความที่อยู่ในสถานะ dead กลับเข้าคิวส่งอีกครั้ง (admin only)
+func (h *Handler) RetryNotification(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	retried, err := h.outboxRepo.Retry(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry notification"})
+		return
+	}
+	if !retried {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead notification not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification queued for retry"})
+}
@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"fmt"
+	"log"
 	"net/http"
-	"time"
 
+	"courtopia-reserve/backend/internal/notify"
+	"courtopia-reserve/backend/internal/rbac"
 	"courtopia-reserve/backend/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -25,8 +27,10 @@ func (h *Handler) GetProfile(c *gin.Context) {
 		"studentId":      user.StudentID,
 		"name":           user.Name,
 		"email":          user.Email,
-		"role":           user.Role,
+		"role":           rbac.Normalize(user.Role),
+		"permissions":    rbac.Permissions(user.Role),
 		"profilePicture": user.ProfilePicture,
+		"language":       user.Language,
 	})
 }
 
@@ -35,30 +39,58 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	claims := c.MustGet("user").(*utils.Claims)
 
 	var req struct {
-		Name  string `json:"name"`
-		Email string `json:"email"`
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+		Language string `json:"language"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
+	user, err := h.userRepo.FindByStudentID(c.Request.Context(), claims.StudentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
 	// อัปเดตข้อมูลใน DB
 	filter := bson.M{"student_id": claims.StudentID}
-	update := bson.M{
-		"$set": bson.M{
-			"name":       req.Name,
-			"email":      req.Email,
-			"updated_at": time.Now(),
-		},
+	set := bson.M{
+		"name":       req.Name,
+		"email":      req.Email,
+		"updated_at": h.clock.Now(),
+	}
+	if req.Language != "" {
+		if !notify.SupportedLanguage(req.Language) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Language must be th or en"})
+			return
+		}
+		set["language"] = req.Language
 	}
+	update := bson.M{"$set": set}
 
-	err := h.userRepo.UpdateOne(c.Request.Context(), filter, update)
-	if err != nil {
+	// เปลี่ยนอีเมลแล้วต้องยืนยันอีเมลใหม่อีกครั้ง
+	emailChanged := req.Email != user.Email
+	if emailChanged {
+		update["$unset"] = bson.M{"email_verified_at": ""}
+	}
+
+	if err := h.userRepo.UpdateOne(c.Request.Context(), filter, update); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
 		return
 	}
 
+	if emailChanged && req.Email != "" {
+		user.Email = req.Email
+		if req.Language != "" {
+			user.Language = req.Language
+		}
+		if err := h.sendVerificationEmail(c.Request.Context(), user, c.ClientIP()); err != nil {
+			log.Printf("Error sending verification email to %s: %v", user.StudentID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully"})
 }
 
@@ -74,7 +106,7 @@ func (h *Handler) UploadProfilePicture(c *gin.Context) {
 	}
 
 	// ตั้งชื่อไฟล์ใหม่เพื่อหลีกเลี่ยงการชนกัน
-	filename := fmt.Sprintf("%s_%d_%s", claims.StudentID, time.Now().Unix(), file.Filename)
+	filename := fmt.Sprintf("%s_%d_%s", claims.StudentID, h.clock.Now().Unix(), file.Filename)
 
 	// กำหนด path สำหรับจัดเก็บไฟล์ (ตัวอย่าง: local storage)
 	filePath := fmt.Sprintf("uploads/profile_pictures/%s", filename)
@@ -94,7 +126,7 @@ func (h *Handler) UploadProfilePicture(c *gin.Context) {
 	update := bson.M{
 		"$set": bson.M{
 			"profile_picture": fullURL,
-			"updated_at":      time.Now(),
+			"updated_at":      h.clock.Now(),
 		},
 	}
 
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/notify"
+	"courtopia-reserve/backend/internal/rbac"
+	"courtopia-reserve/backend/internal/repository"
+	"courtopia-reserve/backend/internal/webhook"
+	"courtopia-reserve/backend/pkg/utils"
+)
+
+// RescheduleBooking ย้ายการจองไปคอร์ทหรือเวลาอื่นในสนามเดิม ด้วยกฎและการตรวจคอร์ทว่างเดียวกับ CreateBooking
+// ช่วงเวลาเดิมจะถูกปล่อยก็ต่อเมื่อได้ช่วงเวลาใหม่แล้ว ถ้าย้ายไม่สำเร็จการจองเดิมยังอยู่เหมือนเดิม
+func (h *Handler) RescheduleBooking(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req models.RescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req == (models.RescheduleRequest{}) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to change, provide courtNumber, bookingDate, startTime or endTime"})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	booking, err := h.bookingRepo.FindByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		return
+	}
+
+	// ย้ายได้เฉพาะเจ้าของหรือผู้ที่มีสิทธิ์ย้ายการจองของผู้อื่นในสนามนี้
+	isOwner := booking.StudentID == claims.StudentID
+	if !isOwner && !(rbac.Can(claims.Role, rbac.BookingsModifyAny) && canAccessVenue(claims, booking.VenueID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to change this booking"})
+		return
+	}
+
+	if booking.Status != "active" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only active bookings can be changed"})
+		return
+	}
+	if booking.CheckedInAt != nil || !booking.StartTime.After(h.clock.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Booking has already started and cannot be changed"})
+		return
+	}
+
+	// ย้ายการจองใกล้เวลาเริ่มเท่ากับยกเลิกช้า จึงไม่อนุญาตภายในช่วง CancelCutoffHours
+	// (ผู้ที่มีสิทธิ์ bypass ของสนามนี้ไม่ติดกฎนี้)
+	bypass := rbac.Can(claims.Role, rbac.BookingsBypassPolicy) && canAccessVenue(claims, booking.VenueID)
+	if !bypass {
+		p, err := h.settingsRepo.GetBookingPolicy(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load booking policy"})
+			return
+		}
+		if booking.StartTime.Sub(h.clock.Now()) < time.Duration(p.CancelCutoffHours)*time.Hour {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Bookings cannot be changed less than %d hours before the start", p.CancelCutoffHours)})
+			return
+		}
+	}
+
+	venue, err := h.venueRepo.FindByID(ctx, booking.VenueID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch venue"})
+		return
+	}
+	loc := h.venueLocation(venue)
+
+	// ค่าที่ไม่ได้ระบุใช้คอร์ทและเวลาเดิมของการจอง
+	if req.CourtNumber == 0 {
+		req.CourtNumber = booking.CourtNumber
+	}
+	if req.BookingDate == "" {
+		req.BookingDate = booking.BookingDate.In(loc).Format("2006-01-02")
+	}
+	if req.StartTime == "" {
+		req.StartTime = booking.StartTime.In(loc).Format("15:04")
+	}
+	if req.EndTime == "" {
+		req.EndTime = booking.EndTime.In(loc).Format("15:04")
+	}
+
+	slot, err := h.validateBookingSlot(ctx, venue, req.CourtNumber, req.BookingDate, req.StartTime, req.EndTime)
+	if err != nil {
+		respondSlotError(c, err)
+		return
+	}
+	if slot.Court.ID == booking.CourtID && slot.StartTime.Equal(booking.StartTime) && slot.EndTime.Equal(booking.EndTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Booking is already at the selected court and time"})
+		return
+	}
+
+	// โควตาคิดจากการจองของเจ้าของการจอง (ผู้ที่มีสิทธิ์ bypass ของสนามนี้ไม่ถูกจำกัด)
+	if !bypass {
+		if err := h.checkBookingPolicy(ctx, booking.StudentID, req.CourtNumber, slot, booking.ID); err != nil {
+			respondSlotError(c, err)
+			return
+		}
+	}
+
+	previous := *booking
+	change := models.BookingChange{
+		From: models.BookingPlacement{
+			CourtID:     booking.CourtID,
+			CourtNumber: booking.CourtNumber,
+			BookingDate: booking.BookingDate,
+			StartTime:   booking.StartTime,
+			EndTime:     booking.EndTime,
+		},
+		To: models.BookingPlacement{
+			CourtID:     slot.Court.ID,
+			CourtNumber: slot.Court.CourtNumber,
+			BookingDate: slot.BookingDate,
+			StartTime:   slot.StartTime,
+			EndTime:     slot.EndTime,
+		},
+		ChangedBy: claims.StudentID,
+		ChangedAt: h.clock.Now(),
+	}
+
+	// ย้ายการจองพร้อมบันทึกอีเมลแจ้งการเปลี่ยนแปลงลง outbox ในขั้นตอนเดียว
+	notice := repository.NewOutboxMessage(notify.TemplateBookingChange, booking)
+	if err := h.bookingRepo.Reschedule(ctx, booking, change, notice); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrSlotUnavailable):
+			c.JSON(http.StatusConflict, gin.H{"error": "Court is not available for the selected time"})
+		case errors.Is(err, repository.ErrCourtUnavailable):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Court is not available for booking"})
+		case errors.Is(err, repository.ErrSlotLockTimeout):
+			c.JSON(http.StatusConflict, gin.H{"error": "Court is being booked by someone else, please try again"})
+		case errors.Is(err, repository.ErrBookingChanged):
+			c.JSON(http.StatusConflict, gin.H{"error": "Booking was changed, please refresh"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change booking"})
+		}
+		return
+	}
+
+	// ช่วงเวลาเดิมว่างแล้ว แจ้งหน้าจองที่เปิดอยู่และให้ผู้ที่รอคิวช่วงเวลานั้นได้คอร์ทแทน
+	h.publishBookingChange(ctx, webhook.EventBookingCancelled, &previous)
+	h.emitBookingEvent(ctx, webhook.EventBookingRescheduled, booking)
+	h.promoteWaitlistAsync(&previous)
+
+	c.JSON(http.StatusOK, toBookingResponse(booking, loc))
+}
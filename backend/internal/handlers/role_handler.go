@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/rbac"
+	"courtopia-reserve/backend/pkg/utils"
+)
+
+// GetRoles แสดง role ทั้งหมดพร้อมสิทธิ์ของแต่ละ role (admin only)
+func (h *Handler) GetRoles(c *gin.Context) {
+	roles := []gin.H{}
+	for _, role := range rbac.Roles() {
+		roles = append(roles, gin.H{
+			"role":        role,
+			"permissions": rbac.Permissions(role),
+		})
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetUsers แสดงรายชื่อผู้ใช้ กรองด้วย ?role= ได้ (admin only)
+func (h *Handler) GetUsers(c *gin.Context) {
+	role := c.Query("role")
+	if role != "" && !rbac.ValidRole(role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		limit = n
+	}
+
+	users, err := h.userRepo.FindAll(c.Request.Context(), role, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// UpdateUserRole เปลี่ยน role และสนามที่ผู้ใช้ดูแล แล้ว logout ทุก session ของผู้ใช้นั้น
+// เพื่อให้ token ใหม่มี role และสนามใหม่ทันที (admin only)
+func (h *Handler) UpdateUserRole(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	var req models.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if !rbac.ValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+
+	venueIDs, ok := h.parseVenueAssignment(c, req)
+	if !ok {
+		return
+	}
+
+	user, err := h.userRepo.FindByStudentID(c.Request.Context(), c.Param("studentId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	currentRole := rbac.Normalize(user.Role)
+	if currentRole == req.Role && sameVenues(user.VenueIDs, venueIDs) {
+		c.JSON(http.StatusOK, user)
+		return
+	}
+
+	// ต้องเหลือ admin อย่างน้อยหนึ่งคนเสมอ
+	if currentRole == rbac.RoleAdmin {
+		if user.StudentID == claims.StudentID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change your own admin role"})
+			return
+		}
+		admins, err := h.userRepo.CountByRole(c.Request.Context(), rbac.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if admins <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot remove the last admin"})
+			return
+		}
+	}
+
+	if err := h.userRepo.UpdateRole(c.Request.Context(), user.ID, req.Role, venueIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	user.Role = req.Role
+	user.VenueIDs = venueIDs
+
+	if _, err := h.sessionRepo.RevokeAllForUser(c.Request.Context(), user.ID, "role_changed"); err != nil {
+		log.Printf("Error revoking sessions after role change for %s: %v", user.StudentID, err)
+	}
+
+	log.Printf("Role of %s changed from %s to %s (venues %v) by %s", user.StudentID, currentRole, req.Role, req.VenueIDs, claims.StudentID)
+	c.JSON(http.StatusOK, user)
+}
+
+// parseVenueAssignment ตรวจสอบสนามที่จะมอบหมายให้ผู้ใช้ดูแล
+// venue_admin ต้องมีอย่างน้อยหนึ่งสนาม staff ไม่ระบุได้ (ทุกสนาม) role อื่นมอบหมายสนามไม่ได้
+func (h *Handler) parseVenueAssignment(c *gin.Context, req models.RoleRequest) ([]primitive.ObjectID, bool) {
+	if len(req.VenueIDs) == 0 {
+		if rbac.VenueScoped(req.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "venueIds is required for the venue_admin role"})
+			return nil, false
+		}
+		return nil, true
+	}
+	if req.Role != rbac.RoleStaff && req.Role != rbac.RoleVenueAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "venueIds can only be set for the staff and venue_admin roles"})
+		return nil, false
+	}
+
+	venueIDs := make([]primitive.ObjectID, 0, len(req.VenueIDs))
+	for _, idStr := range req.VenueIDs {
+		id, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid venue ID", "venueId": idStr})
+			return nil, false
+		}
+		if !slices.Contains(venueIDs, id) {
+			venueIDs = append(venueIDs, id)
+		}
+	}
+
+	venues, err := h.venueRepo.FindAll(c.Request.Context(), venueIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch venues"})
+		return nil, false
+	}
+	if len(venues) != len(venueIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Venue not found"})
+		return nil, false
+	}
+
+	return venueIDs, true
+}
+
+// sameVenues ตรวจว่ารายการสนามสองชุดเหมือนกันโดยไม่สนใจลำดับ
+func sameVenues(a, b []primitive.ObjectID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, id := range a {
+		if !slices.Contains(b, id) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// GetCourtSchedule ดึงตารางช่วงเวลาว่างและไม่ว่างของทุกคอร์ทในสนามตลอดเวลาทำการของวัน
+func (h *Handler) GetCourtSchedule(c *gin.Context) {
+	venue, ok := h.findVenue(c, venueParam(c))
+	if !ok {
+		return
+	}
+
+	dateStr := c.Query("date")
+	if dateStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date is required"})
+		return
+	}
+
+	loc := h.venueLocation(venue)
+	bookingDate, err := time.ParseInLocation("2006-01-02", dateStr, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format, use YYYY-MM-DD"})
+		return
+	}
+
+	hours, err := h.settingsRepo.GetOperatingHours(c.Request.Context(), venue.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load operating hours"})
+		return
+	}
+
+	response := models.ScheduleResponse{
+		VenueID:     venue.ID.Hex(),
+		BookingDate: dateStr,
+		SlotMinutes: hours.SlotMinutes,
+		Courts:      []models.CourtSchedule{},
+	}
+
+	// วันที่สนามปิดไม่มีช่วงเวลาให้จอง
+	day, ok := hoursForDay(hours, bookingDate.Weekday())
+	if !ok || day.IsClosed {
+		response.IsClosed = true
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	response.OpenTime = day.OpenTime
+	response.CloseTime = day.CloseTime
+
+	_, openTime, closeTime, perr := parseSlotTimes(loc, dateStr, day.OpenTime, day.CloseTime)
+	if perr != nil {
+		respondSlotError(c, perr)
+		return
+	}
+
+	courts, err := h.courtRepo.FindActiveCourts(c.Request.Context(), venue.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch courts"})
+		return
+	}
+
+	booked, err := h.bookingRepo.FindDaySchedule(c.Request.Context(), venue.ID, bookingDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule"})
+		return
+	}
+
+	blackouts, err := h.blackoutRepo.FindOverlapping(c.Request.Context(), venue.ID, 0, openTime, closeTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule"})
+		return
+	}
+
+	for _, court := range courts {
+		occupied := make([]occupiedInterval, 0, len(booked[court.CourtNumber]))
+		for _, b := range booked[court.CourtNumber] {
+			occupied = append(occupied, occupiedInterval{b.StartTime, b.EndTime, "booked"})
+		}
+		for _, blackout := range blackouts {
+			if slices.Contains(blackout.CourtNumbers, court.CourtNumber) {
+				occupied = append(occupied, occupiedInterval{blackout.StartTime, blackout.EndTime, "blocked"})
+			}
+		}
+
+		response.Courts = append(response.Courts, models.CourtSchedule{
+			CourtNumber: court.CourtNumber,
+			Name:        court.Name,
+			Intervals:   buildIntervals(openTime, closeTime, occupied),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// occupiedInterval is a part of a court's day that cannot be booked
+type occupiedInterval struct {
+	start  time.Time
+	end    time.Time
+	status string // booked, blocked
+}
+
+// buildIntervals แบ่งช่วงเวลาเปิดทำการเป็นช่วงว่างและช่วงที่ถูกจองหรือปิดปรับปรุง
+// เวลาใน response เป็นเวลาท้องถิ่นตาม timezone ของ openTime
+func buildIntervals(openTime, closeTime time.Time, occupied []occupiedInterval) []models.ScheduleInterval {
+	loc := openTime.Location()
+
+	sort.SliceStable(occupied, func(i, j int) bool {
+		return occupied[i].start.Before(occupied[j].start)
+	})
+
+	intervals := []models.ScheduleInterval{}
+	add := func(start, end time.Time, status string) {
+		if !end.After(start) {
+			return
+		}
+		// รวมช่วงที่ติดกันและมีสถานะเดียวกัน
+		if n := len(intervals); n > 0 && intervals[n-1].Status == status && intervals[n-1].EndTime == start.In(loc).Format("15:04") {
+			intervals[n-1].EndTime = end.In(loc).Format("15:04")
+			return
+		}
+		intervals = append(intervals, models.ScheduleInterval{
+			StartTime: start.In(loc).Format("15:04"),
+			EndTime:   end.In(loc).Format("15:04"),
+			Status:    status,
+		})
+	}
+
+	cursor := openTime
+	for _, o := range occupied {
+		start, end := o.start, o.end
+		if start.Before(cursor) {
+			start = cursor
+		}
+		if end.After(closeTime) {
+			end = closeTime
+		}
+		if !end.After(start) {
+			continue
+		}
+		add(cursor, start, "free")
+		add(start, end, o.status)
+		cursor = end
+	}
+	add(cursor, closeTime, "free")
+
+	return intervals
+}
@@ -0,0 +1,456 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/notify"
+	"courtopia-reserve/backend/internal/policy"
+	"courtopia-reserve/backend/internal/rbac"
+	"courtopia-reserve/backend/internal/repository"
+	"courtopia-reserve/backend/internal/webhook"
+	"courtopia-reserve/backend/pkg/utils"
+)
+
+const (
+	// maxSeriesOccurrences จำกัดจำนวนการจองที่สร้างจากการจองแบบประจำหนึ่งชุด
+	maxSeriesOccurrences = 100
+	// maxSeriesSpan จำกัดระยะเวลาของการจองแบบประจำ
+	maxSeriesSpan = 365 * 24 * time.Hour
+)
+
+// seriesOccurrences คืนวันที่ของแต่ละครั้งตามรูปแบบรายสัปดาห์
+// สัปดาห์นับจากสัปดาห์ (อาทิตย์-เสาร์) ที่มี startDate
+func seriesOccurrences(startDate time.Time, weekdays []int, intervalWeeks int, until *time.Time, count int) []time.Time {
+	weekStart := startDate.AddDate(0, 0, -int(startDate.Weekday()))
+
+	var dates []time.Time
+	for date := startDate; len(dates) < maxSeriesOccurrences; date = date.AddDate(0, 0, 1) {
+		if until != nil && date.After(*until) {
+			break
+		}
+		if count > 0 && len(dates) >= count {
+			break
+		}
+		if date.Sub(startDate) > maxSeriesSpan {
+			break
+		}
+
+		// ปัดเศษเพราะวันที่เปลี่ยนเวลา (daylight saving) ไม่ได้ยาว 24 ชั่วโมง
+		week := int(math.Round(date.Sub(weekStart).Hours()/24)) / 7
+		if week%intervalWeeks == 0 && slices.Contains(weekdays, int(date.Weekday())) {
+			dates = append(dates, date)
+		}
+	}
+
+	return dates
+}
+
+// CreateSeries สร้างการจองแบบประจำรายสัปดาห์ (สำหรับผู้ดูแลชมรมและ admin)
+func (h *Handler) CreateSeries(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+	if !rbac.Can(claims.Role, rbac.SeriesCreate) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only clubs and admins can create recurring bookings"})
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.SeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	seen := make(map[int]bool, len(req.Weekdays))
+	for _, weekday := range req.Weekdays {
+		if weekday < 0 || weekday > 6 || seen[weekday] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weekdays must be unique values from 0 (Sunday) to 6 (Saturday)"})
+			return
+		}
+		seen[weekday] = true
+	}
+
+	if req.IntervalWeeks == 0 {
+		req.IntervalWeeks = 1
+	}
+	if req.IntervalWeeks < 1 || req.IntervalWeeks > 4 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "intervalWeeks must be between 1 and 4"})
+		return
+	}
+
+	venue, err := h.resolveVenue(c.Request.Context(), req.VenueID)
+	if err != nil {
+		respondSlotError(c, err)
+		return
+	}
+
+	// วันที่ของชุดการจองเป็นวันตามเวลาท้องถิ่นของสนาม
+	loc := h.venueLocation(venue)
+	startDate, err := time.ParseInLocation("2006-01-02", req.StartDate, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format, use YYYY-MM-DD"})
+		return
+	}
+
+	var until *time.Time
+	if req.Until != "" {
+		untilDate, err := time.ParseInLocation("2006-01-02", req.Until, loc)
+		if err != nil || untilDate.Before(startDate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "until must be a date on or after startDate (YYYY-MM-DD)"})
+			return
+		}
+		until = &untilDate
+	}
+	if until == nil && req.Count <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Either until or count is required"})
+		return
+	}
+	if req.Count < 0 || req.Count > maxSeriesOccurrences {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "count must be between 1 and 100"})
+		return
+	}
+
+	court, err := h.findBookableCourt(c.Request.Context(), venue.ID, req.CourtNumber)
+	if err != nil {
+		respondSlotError(c, err)
+		return
+	}
+
+	dates := seriesOccurrences(startDate, req.Weekdays, req.IntervalWeeks, until, req.Count)
+	if len(dates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The pattern does not produce any bookings"})
+		return
+	}
+
+	series := &models.BookingSeries{
+		ID:            primitive.NewObjectID(),
+		UserID:        userID,
+		StudentID:     claims.StudentID,
+		VenueID:       venue.ID,
+		CourtID:       court.ID,
+		CourtNumber:   court.CourtNumber,
+		Weekdays:      req.Weekdays,
+		IntervalWeeks: req.IntervalWeeks,
+		StartDate:     startDate,
+		Until:         until,
+		Count:         req.Count,
+		StartTime:     req.StartTime,
+		EndTime:       req.EndTime,
+	}
+	if err := h.seriesRepo.Create(c.Request.Context(), series); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recurring booking"})
+		return
+	}
+
+	response := models.SeriesResponse{
+		Series:    series,
+		Created:   []models.SeriesOccurrenceResult{},
+		Conflicts: []models.SeriesOccurrenceResult{},
+	}
+
+	// แต่ละครั้งต้องผ่านกฎการจองเหมือนการจองทั่วไป (ผู้ที่มีสิทธิ์ bypass ของสนามนี้ไม่ถูกจำกัด)
+	bypass := rbac.Can(claims.Role, rbac.BookingsBypassPolicy) && canAccessVenue(claims, venue.ID)
+
+	// จองทีละครั้ง และรายงานครั้งที่จองไม่ได้แยกไว้
+	for _, date := range dates {
+		dateStr := date.Format("2006-01-02")
+		result := models.SeriesOccurrenceResult{BookingDate: dateStr}
+
+		slot, err := h.validateSlotTimes(c.Request.Context(), venue, dateStr, req.StartTime, req.EndTime)
+		if err != nil {
+			var se *slotError
+			if !errors.As(err, &se) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check court availability"})
+				return
+			}
+			result.Error = se.message
+			response.Conflicts = append(response.Conflicts, result)
+			continue
+		}
+
+		// ครั้งก่อนหน้าที่จองได้แล้วนับรวมในโควตาของครั้งถัดไป
+		if !bypass {
+			if err := h.checkBookingPolicy(c.Request.Context(), claims.StudentID, court.CourtNumber, slot, primitive.NilObjectID); err != nil {
+				var v *policy.Violation
+				if !errors.As(err, &v) {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check booking policy"})
+					return
+				}
+				result.Error = v.Message
+				result.Rule = v.Rule
+				response.Conflicts = append(response.Conflicts, result)
+				continue
+			}
+		}
+
+		booking := &models.Booking{
+			ID:          primitive.NewObjectID(),
+			UserID:      userID,
+			StudentID:   claims.StudentID,
+			VenueID:     venue.ID,
+			CourtID:     court.ID,
+			CourtNumber: court.CourtNumber,
+			BookingDate: slot.BookingDate,
+			StartTime:   slot.StartTime,
+			EndTime:     slot.EndTime,
+			UserEmail:   claims.Email,
+			SeriesID:    &series.ID,
+		}
+
+		// แต่ละครั้งที่จองได้มีอีเมลยืนยันพร้อมไฟล์ปฏิทินของตัวเอง
+		confirmation := repository.NewOutboxMessage(notify.TemplateBookingConfirmation, booking)
+		err = h.bookingRepo.CreateIfAvailable(c.Request.Context(), booking, confirmation)
+		switch {
+		case err == nil:
+			result.BookingID = booking.ID.Hex()
+			response.Created = append(response.Created, result)
+			h.emitBookingEvent(c.Request.Context(), webhook.EventBookingCreated, booking)
+		case errors.Is(err, repository.ErrSlotUnavailable):
+			result.Error = "Court is not available for the selected time"
+			response.Conflicts = append(response.Conflicts, result)
+		case errors.Is(err, repository.ErrCourtUnavailable):
+			result.Error = "Court is not available for booking"
+			response.Conflicts = append(response.Conflicts, result)
+		case errors.Is(err, repository.ErrSlotLockTimeout):
+			result.Error = "Court is being booked by someone else, please try again"
+			response.Conflicts = append(response.Conflicts, result)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create booking"})
+			return
+		}
+	}
+
+	// ไม่มีครั้งไหนจองได้เลย ไม่ต้องเก็บชุดการจองไว้
+	if len(response.Created) == 0 {
+		if err := h.seriesRepo.Delete(c.Request.Context(), series.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recurring booking"})
+			return
+		}
+		response.Series = nil
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetSeries ดึงข้อมูลการจองแบบประจำพร้อมการจองแต่ละครั้ง
+func (h *Handler) GetSeries(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	series, err := h.seriesRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring booking not found"})
+		return
+	}
+
+	if series.StudentID != claims.StudentID && !(rbac.Can(claims.Role, rbac.BookingsReadAll) && canAccessVenue(claims, series.VenueID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to view this recurring booking"})
+		return
+	}
+
+	bookings, err := h.bookingRepo.FindBySeriesID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookings"})
+		return
+	}
+
+	loc, err := h.locationOf(c.Request.Context(), series.VenueID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookings"})
+		return
+	}
+
+	occurrences := make([]models.BookingResponse, 0, len(bookings))
+	for _, booking := range bookings {
+		occurrences = append(occurrences, toBookingResponse(booking, loc))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series":      series,
+		"occurrences": occurrences,
+	})
+}
+
+// CancelSeries ยกเลิกการจองแบบประจำครั้งเดียว ทุกครั้งต่อจากนี้ หรือทั้งชุด
+func (h *Handler) CancelSeries(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	var req models.SeriesCancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	series, err := h.seriesRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring booking not found"})
+		return
+	}
+
+	if series.StudentID != claims.StudentID && !(rbac.Can(claims.Role, rbac.BookingsCancelAny) && canAccessVenue(claims, series.VenueID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to cancel this recurring booking"})
+		return
+	}
+
+	switch req.Scope {
+	case "occurrence":
+		bookingID, err := primitive.ObjectIDFromHex(req.BookingID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+			return
+		}
+		booking, err := h.bookingRepo.FindByID(c.Request.Context(), bookingID)
+		if err != nil || booking.SeriesID == nil || *booking.SeriesID != series.ID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found in this recurring booking"})
+			return
+		}
+		if booking.Status != "active" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Booking is not active"})
+			return
+		}
+		// ครั้งเดียวของการจองแบบประจำใช้กฎการยกเลิกเดียวกับการจองทั่วไป
+		p, err := h.settingsRepo.GetBookingPolicy(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load booking policy"})
+			return
+		}
+		cancellation, err := h.newCancellation(claims, booking, req.Reason, p)
+		if err != nil {
+			respondSlotError(c, err)
+			return
+		}
+		notice := repository.NewOutboxMessage(notify.TemplateBookingCancellation, booking)
+		if err := h.bookingRepo.CancelBooking(c.Request.Context(), bookingID, cancellation, notice); err != nil {
+			if errors.Is(err, repository.ErrBookingChanged) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Booking was changed, please refresh"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel booking"})
+			return
+		}
+		booking.Status = "cancelled"
+		booking.Cancellation = &cancellation
+		h.emitBookingEvent(c.Request.Context(), webhook.EventBookingCancelled, booking)
+		h.promoteWaitlistAsync(booking)
+		if cancellation.Late {
+			h.applyNoShowBan(c.Request.Context(), p, booking, cancellation.CancelledAt)
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Booking cancelled successfully", "cancelled": 1})
+
+	case "future", "all":
+		// ยกเลิกเฉพาะครั้งที่ยังไม่เริ่ม
+		from := h.clock.Now()
+		if req.Scope == "future" && req.From != "" {
+			loc, err := h.locationOf(c.Request.Context(), series.VenueID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel bookings"})
+				return
+			}
+			fromDate, err := time.ParseInLocation("2006-01-02", req.From, loc)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date format, use YYYY-MM-DD"})
+				return
+			}
+			if fromDate.After(from) {
+				from = fromDate
+			}
+		}
+
+		occurrences, err := h.bookingRepo.FindActiveSeriesBookings(c.Request.Context(), series.ID, from)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel bookings"})
+			return
+		}
+		p, err := h.settingsRepo.GetBookingPolicy(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load booking policy"})
+			return
+		}
+
+		// แต่ละครั้งใช้กฎการยกเลิกเดียวกับการจองทั่วไป ครั้งที่อยู่ในช่วง cut-off นับเป็นการยกเลิกช้า
+		// ตรวจทุกครั้งก่อนยกเลิก เพื่อไม่ให้ยกเลิกไปเพียงบางส่วนเมื่อคำขอไม่ผ่านกฎ
+		cancellations := make([]models.BookingCancellation, len(occurrences))
+		for i, booking := range occurrences {
+			cancellations[i], err = h.newCancellation(claims, booking, req.Reason, p)
+			if err != nil {
+				respondSlotError(c, err)
+				return
+			}
+		}
+
+		// แต่ละครั้งถูกยกเลิกพร้อมอีเมลแจ้งของตัวเอง ครั้งที่ถูกเปลี่ยนไปแล้วระหว่างนี้จะถูกข้าม
+		cancelled := []*models.Booking{}
+		late := 0
+		var cancelErr error
+		for i, booking := range occurrences {
+			notice := repository.NewOutboxMessage(notify.TemplateBookingCancellation, booking)
+			err := h.bookingRepo.CancelBooking(c.Request.Context(), booking.ID, cancellations[i], notice)
+			if errors.Is(err, repository.ErrBookingChanged) {
+				continue
+			}
+			if err != nil {
+				cancelErr = err
+				break
+			}
+			booking.Status = "cancelled"
+			booking.Cancellation = &cancellations[i]
+			cancelled = append(cancelled, booking)
+			if cancellations[i].Late {
+				late++
+			}
+		}
+
+		// ให้ผู้ที่รอคิวช่วงเวลาของแต่ละครั้งที่ยกเลิกแล้วได้คอร์ทแทน แม้จะยกเลิกไม่ครบทุกครั้ง
+		h.emitBookingEvents(c.Request.Context(), webhook.EventBookingCancelled, cancelled)
+		for _, booking := range cancelled {
+			h.promoteWaitlistAsync(booking)
+		}
+
+		// การยกเลิกช้าแต่ละครั้งถูกนับแยกกันในการห้ามจองชั่วคราว
+		if late > 0 {
+			h.applyNoShowBan(c.Request.Context(), p, cancelled[0], h.clock.Now())
+		}
+
+		if cancelErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel bookings"})
+			return
+		}
+
+		if req.Scope == "all" {
+			if err := h.seriesRepo.UpdateStatus(c.Request.Context(), series.ID, "cancelled"); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel recurring booking"})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Bookings cancelled successfully", "cancelled": len(cancelled), "late": late})
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be one of occurrence, future, all"})
+	}
+}
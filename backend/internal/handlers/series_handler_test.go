@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"courtopia-reserve/backend/internal/clock"
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/policy"
+	"courtopia-reserve/backend/internal/rbac"
+)
+
+// seriesTestCase คือผู้สร้างการจองแบบประจำหนึ่งแบบพร้อมผลที่คาดไว้
+type seriesTestCase struct {
+	name        string
+	role        string
+	policy      func(p *models.BookingPolicy)
+	prepare     func(t *testing.T, h *Handler, user *models.User)
+	wantStatus  int
+	wantCreated int
+	wantRule    string
+}
+
+// runSeriesPolicyTest สร้างการจองแบบประจำทุกวันจันทร์ 4 ครั้งตั้งแต่ 2030-01-07 18:00-19:00
+// โดยตรึงเวลาไว้ที่ 2030-01-07 09:00 และตรวจจำนวนครั้งที่จองได้กับกฎที่ครั้งที่เหลือไม่ผ่าน
+func runSeriesPolicyTest(t *testing.T, tt seriesTestCase) {
+	t.Helper()
+	h, db := newTestHandler(t)
+	ctx := context.Background()
+
+	venue, court := newTestCourt(t, h, 1)
+	loc := mustLocation(t, venue.Timezone)
+	h.UseClock(clock.Fixed(time.Date(2030, 1, 7, 9, 0, 0, 0, loc)))
+
+	// ปิดกฎทั้งหมด แล้วเปิดเฉพาะกฎที่ทดสอบ
+	p := &models.BookingPolicy{}
+	if tt.policy != nil {
+		tt.policy(p)
+	}
+	if err := h.settingsRepo.UpdateBookingPolicy(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+
+	token := newTestUser(t, h, "65000001", tt.role)
+	user, err := h.userRepo.FindByStudentID(ctx, "65000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tt.prepare != nil {
+		tt.prepare(t, h, user)
+	}
+
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	body := fmt.Sprintf(`{"venueId":%q,"courtNumber":1,"weekdays":[1],"startDate":"2030-01-07","count":4,"startTime":"18:00","endTime":"19:00"}`, venue.ID.Hex())
+	req := httptest.NewRequest(http.MethodPost, "/api/bookings/series", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != tt.wantStatus {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+	}
+
+	var response models.SeriesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Created) != tt.wantCreated {
+		t.Errorf("got %d created occurrences, want %d", len(response.Created), tt.wantCreated)
+	}
+	if len(response.Conflicts) != 4-tt.wantCreated {
+		t.Errorf("got %d conflicts, want %d", len(response.Conflicts), 4-tt.wantCreated)
+	}
+	for _, conflict := range response.Conflicts {
+		if conflict.Rule != tt.wantRule {
+			t.Errorf("%s: got rule %q (%s), want %q", conflict.BookingDate, conflict.Rule, conflict.Error, tt.wantRule)
+		}
+	}
+
+	active, err := db.Collection("bookings").CountDocuments(ctx, bson.M{"court_id": court.ID, "status": "active"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active != int64(tt.wantCreated) {
+		t.Errorf("got %d active bookings, want %d", active, tt.wantCreated)
+	}
+}
+
+// TestCreateSeriesAppliesPolicy ตรวจว่าแต่ละครั้งของการจองแบบประจำผ่านกฎการจองเหมือนการจองทั่วไป
+func TestCreateSeriesAppliesPolicy(t *testing.T) {
+	tests := []seriesTestCase{
+		{
+			name:        "no limits",
+			role:        rbac.RoleClubManager,
+			wantStatus:  http.StatusCreated,
+			wantCreated: 4,
+		},
+		{
+			name:        "active booking cap",
+			role:        rbac.RoleClubManager,
+			policy:      func(p *models.BookingPolicy) { p.MaxActiveBookings = 2 },
+			wantStatus:  http.StatusCreated,
+			wantCreated: 2,
+			wantRule:    policy.RuleMaxActiveBookings,
+		},
+		{
+			// 2030-01-21 18:00 เกิน 14 วันนับจาก 2030-01-07 09:00
+			name:        "booking horizon",
+			role:        rbac.RoleClubManager,
+			policy:      func(p *models.BookingPolicy) { p.MaxDaysAhead = 14 },
+			wantStatus:  http.StatusCreated,
+			wantCreated: 2,
+			wantRule:    policy.RuleMaxDaysAhead,
+		},
+		{
+			// ผู้ที่ถูกห้ามจองหลังไม่มาใช้คอร์ทจองผ่านการจองแบบประจำไม่ได้ ทั้งชุดจึงไม่ถูกสร้าง
+			name: "banned club manager",
+			role: rbac.RoleClubManager,
+			prepare: func(t *testing.T, h *Handler, user *models.User) {
+				if err := h.userRepo.SetBookingBan(context.Background(), user.ID, h.clock.Now().AddDate(0, 0, 7)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantStatus:  http.StatusConflict,
+			wantCreated: 0,
+			wantRule:    policy.RuleNoShowBan,
+		},
+		{
+			name:   "unverified club manager",
+			role:   rbac.RoleClubManager,
+			policy: func(p *models.BookingPolicy) { p.UnverifiedMaxActiveBookings = 1 },
+			prepare: func(t *testing.T, h *Handler, user *models.User) {
+				unset := bson.M{"$unset": bson.M{"email_verified_at": ""}}
+				if err := h.userRepo.UpdateOne(context.Background(), bson.M{"_id": user.ID}, unset); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantStatus:  http.StatusCreated,
+			wantCreated: 1,
+			wantRule:    policy.RuleUnverifiedEmail,
+		},
+		{
+			name:        "admin bypasses the policy",
+			role:        rbac.RoleAdmin,
+			policy:      func(p *models.BookingPolicy) { p.MaxActiveBookings = 1 },
+			wantStatus:  http.StatusCreated,
+			wantCreated: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSeriesPolicyTest(t, tt)
+		})
+	}
+}
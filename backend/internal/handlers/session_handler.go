@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/pkg/utils"
+)
+
+// errSessionRevoked is returned when a refresh token is used on a session that has ended
+var errSessionRevoked = errors.New("session has been revoked")
+
+// startSession สร้าง session ใหม่ให้ผู้ใช้ที่ login สำเร็จ แล้วออก access token และ refresh token
+func (h *Handler) startSession(c *gin.Context, user *models.User) (*models.LoginResponse, error) {
+	session := &models.Session{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		StudentID: user.StudentID,
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+		ExpiresAt: h.clock.Now().Add(h.cfg.RefreshTokenTTL),
+	}
+
+	refreshToken, hash, err := utils.GenerateOpaqueToken(session.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	session.RefreshTokenHash = hash
+
+	if err := h.sessionRepo.Create(c.Request.Context(), session); err != nil {
+		return nil, err
+	}
+
+	return h.loginResponse(user, session.ID.Hex(), refreshToken)
+}
+
+// loginResponse ออก access token ของ session และรวมกับ refresh token เป็น response
+func (h *Handler) loginResponse(user *models.User, sessionID string, refreshToken string) (*models.LoginResponse, error) {
+	token, err := utils.GenerateToken(user, h.jwtSecret, sessionID, h.cfg.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(h.cfg.AccessTokenTTL / time.Second),
+		StudentID:    user.StudentID,
+		Name:         user.Name,
+		Role:         user.Role,
+	}, nil
+}
+
+// rotateSession ตรวจ refresh token และเปลี่ยนเป็น token ใหม่ token เดิมใช้ซ้ำไม่ได้อีก
+// ถ้ามีคนใช้ token ที่ถูกเปลี่ยนไปแล้ว แปลว่า token อาจถูกขโมย จึงยกเลิกทั้ง session
+func (h *Handler) rotateSession(ctx context.Context, refreshToken string) (*models.Session, string, error) {
+	idHex, ok := utils.OpaqueTokenID(refreshToken)
+	if !ok {
+		return nil, "", errSessionRevoked
+	}
+	sessionID, err := primitive.ObjectIDFromHex(idHex)
+	if err != nil {
+		return nil, "", errSessionRevoked
+	}
+
+	newToken, newHash, err := utils.GenerateOpaqueToken(idHex)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rotated, err := h.sessionRepo.Rotate(ctx, sessionID, utils.HashToken(refreshToken), newHash, h.clock.Now().Add(h.cfg.RefreshTokenTTL))
+	if err != nil {
+		return nil, "", err
+	}
+	if !rotated {
+		session, err := h.sessionRepo.FindByID(ctx, sessionID)
+		if err == nil && session.RevokedAt == nil && session.ExpiresAt.After(h.clock.Now()) {
+			log.Printf("Refresh token reuse detected for session %s of %s, revoking session", idHex, session.StudentID)
+			if err := h.sessionRepo.Revoke(ctx, sessionID, "refresh_reuse"); err != nil {
+				log.Printf("Error revoking session %s: %v", idHex, err)
+			}
+		}
+		return nil, "", errSessionRevoked
+	}
+
+	session, err := h.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		return nil, "", err
+	}
+	return session, newToken, nil
+}
+
+// RefreshToken ออก access token ใหม่จาก refresh token และหมุน refresh token
+func (h *Handler) RefreshToken(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	session, refreshToken, err := h.rotateSession(c.Request.Context(), req.RefreshToken)
+	if errors.Is(err, errSessionRevoked) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	// โหลดผู้ใช้ใหม่ทุกครั้ง เพื่อให้ role ที่เปลี่ยนมีผลใน access token ถัดไป
+	user, err := h.userRepo.FindByID(c.Request.Context(), session.UserID)
+	if err != nil {
+		_ = h.sessionRepo.Revoke(c.Request.Context(), session.ID, "user_missing")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+
+	response, err := h.loginResponse(user, session.ID.Hex(), refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout ยกเลิก session ปัจจุบันและ access token ที่ใช้เรียก
+func (h *Handler) Logout(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	if sessionID, err := primitive.ObjectIDFromHex(claims.SessionID); err == nil {
+		if err := h.sessionRepo.Revoke(c.Request.Context(), sessionID, "logout"); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+	}
+	if err := h.revokeAccessToken(c.Request.Context(), claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAll ยกเลิกทุก session ของผู้ใช้ เช่น เมื่อทำโทรศัพท์หายหรือสงสัยว่ารหัสผ่านรั่ว
+func (h *Handler) LogoutAll(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	userID, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	revoked, err := h.sessionRepo.RevokeAllForUser(c.Request.Context(), userID, "logout_all")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out sessions"})
+		return
+	}
+	if err := h.revokeAccessToken(c.Request.Context(), claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All sessions logged out", "sessions": revoked})
+}
+
+// revokeAccessToken ยกเลิก access token ตาม jti จนกว่าจะหมดอายุเอง
+func (h *Handler) revokeAccessToken(ctx context.Context, claims *utils.Claims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	return h.sessionRepo.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time)
+}
+
+// checkTokenRevocation ตรวจว่า session และ jti ของ token ยังไม่ถูกยกเลิก
+func (h *Handler) checkTokenRevocation(ctx context.Context, claims *utils.Claims) (bool, error) {
+	sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
+	if err != nil {
+		// token ที่ออกก่อนมี session ไม่สามารถยกเลิกได้ จึงไม่ยอมรับ
+		return false, nil
+	}
+
+	active, err := h.sessionRepo.IsActive(ctx, sessionID)
+	if err != nil || !active {
+		return false, err
+	}
+
+	revoked, err := h.sessionRepo.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return false, err
+	}
+	return !revoked, nil
+}
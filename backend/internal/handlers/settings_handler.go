@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// GetOperatingHours ดึงเวลาทำการและกฎของ slot การจองของสนาม (สำหรับผู้ดูแลสนาม)
+func (h *Handler) GetOperatingHours(c *gin.Context) {
+	venue, ok := h.findManagedVenue(c, venueParam(c))
+	if !ok {
+		return
+	}
+
+	hours, err := h.settingsRepo.GetOperatingHours(c.Request.Context(), venue.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load operating hours"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hours)
+}
+
+// UpdateOperatingHours แก้ไขเวลาทำการและกฎของ slot การจองของสนาม (สำหรับผู้ดูแลสนาม)
+func (h *Handler) UpdateOperatingHours(c *gin.Context) {
+	venue, ok := h.findManagedVenue(c, venueParam(c))
+	if !ok {
+		return
+	}
+
+	var req models.OperatingHours
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if msg := validateOperatingHours(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if err := h.settingsRepo.UpdateOperatingHours(c.Request.Context(), venue.ID, &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update operating hours"})
+		return
+	}
+
+	c.JSON(http.StatusOK, req)
+}
+
+// validateOperatingHours ตรวจสอบการตั้งค่าเวลาทำการ คืนข้อความ error หรือสตริงว่างถ้าถูกต้อง
+func validateOperatingHours(hours *models.OperatingHours) string {
+	if hours.SlotMinutes < 5 || hours.SlotMinutes > 60 || 60%hours.SlotMinutes != 0 {
+		return "slotMinutes must divide an hour evenly (5-60)"
+	}
+
+	if hours.MinDurationMinutes%hours.SlotMinutes != 0 || hours.MinDurationMinutes > int(maxBookingDuration.Minutes()) {
+		return "minDurationMinutes must be a multiple of slotMinutes and at most 120"
+	}
+
+	if len(hours.Days) != 7 {
+		return "days must contain all 7 weekdays"
+	}
+
+	seen := make(map[int]bool, 7)
+	for _, day := range hours.Days {
+		if day.Weekday < 0 || day.Weekday > 6 || seen[day.Weekday] {
+			return "days must contain each weekday (0-6) exactly once"
+		}
+		seen[day.Weekday] = true
+
+		if day.IsClosed {
+			continue
+		}
+
+		openMinutes, err := clockMinutes(day.OpenTime)
+		if err != nil {
+			return "Invalid open time format, use HH:MM"
+		}
+		closeMinutes, err := clockMinutes(day.CloseTime)
+		if err != nil {
+			return "Invalid close time format, use HH:MM"
+		}
+		if closeMinutes <= openMinutes {
+			return "Close time must be after open time"
+		}
+		if openMinutes%hours.SlotMinutes != 0 || closeMinutes%hours.SlotMinutes != 0 {
+			return "Open and close times must be on slot boundaries"
+		}
+	}
+
+	return ""
+}
+
+// GetBookingPolicy ดึงกฎการใช้งานที่เป็นธรรม (สำหรับ admin)
+func (h *Handler) GetBookingPolicy(c *gin.Context) {
+	p, err := h.settingsRepo.GetBookingPolicy(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load booking policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// UpdateBookingPolicy แก้ไขกฎการใช้งานที่เป็นธรรม (สำหรับ admin) ค่า 0 หมายถึงไม่จำกัด
+func (h *Handler) UpdateBookingPolicy(c *gin.Context) {
+	var req models.BookingPolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if req.MaxActiveBookings < 0 || req.MaxHoursPerDay < 0 || req.MaxHoursPerWeek < 0 || req.MaxDaysAhead < 0 ||
+		req.CheckInOpensMinutes < 0 || req.CheckInGraceMinutes < 0 ||
+		req.NoShowLimit < 0 || req.NoShowWindowDays < 0 || req.NoShowBanDays < 0 ||
+		req.UnverifiedMaxActiveBookings < 0 || req.CancelCutoffHours < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Limits cannot be negative"})
+		return
+	}
+
+	if err := h.settingsRepo.UpdateBookingPolicy(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update booking policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, req)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/realtime"
+	"courtopia-reserve/backend/internal/webhook"
+)
+
+// streamHeartbeat ส่ง comment เป็นระยะ เพื่อไม่ให้ proxy ตัดการเชื่อมต่อที่เงียบนานเกินไป
+const streamHeartbeat = 25 * time.Second
+
+// StreamCourtAvailability ส่ง Server-Sent Events เมื่อมีการจอง ยกเลิก หรือเปลี่ยนสถานะคอร์ทของสนามในวันที่ระบุ
+func (h *Handler) StreamCourtAvailability(c *gin.Context) {
+	venue, ok := h.findVenue(c, venueParam(c))
+	if !ok {
+		return
+	}
+
+	date := c.Query("date")
+	if date == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date is required"})
+		return
+	}
+	if _, err := time.ParseInLocation("2006-01-02", date, h.venueLocation(venue)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format, use YYYY-MM-DD"})
+		return
+	}
+
+	sub := h.broker.Subscribe(venue.ID.Hex(), date)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // ปิด buffering ของ nginx
+
+	// บอกให้ browser รอ 3 วินาทีก่อน reconnect
+	c.Writer.WriteString("retry: 3000\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+			return true
+		case event, ok := <-sub.Events():
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		}
+	})
+}
+
+// publishBookingChange แจ้งหน้าจองที่เปิดอยู่ว่าช่วงเวลาของการจองนี้เปลี่ยนไป
+// ใช้ชื่อเหตุการณ์เดียวกับ webhook แล้วแปลงเป็นเหตุการณ์ของ SSE วันและเวลาเป็นเวลาท้องถิ่นของสนาม
+func (h *Handler) publishBookingChange(ctx context.Context, event string, booking *models.Booking) {
+	var eventType string
+	switch event {
+	case webhook.EventBookingCreated, webhook.EventBookingRescheduled:
+		// ช่วงเวลาเดิมของการจองที่ถูกย้ายแจ้งแยกเป็น booking_cancelled ตอนย้าย
+		eventType = realtime.EventBookingCreated
+	case webhook.EventBookingCancelled:
+		eventType = realtime.EventBookingCancelled
+	case webhook.EventBookingNoShow:
+		eventType = realtime.EventBookingReleased
+	default:
+		// completed ไม่ทำให้ช่วงเวลาที่ยังจองได้เปลี่ยน
+		return
+	}
+
+	loc, err := h.locationOf(ctx, booking.VenueID)
+	if err != nil {
+		log.Printf("Error fetching venue %s for court stream: %v", booking.VenueID.Hex(), err)
+		return
+	}
+
+	h.broker.Publish(realtime.Event{
+		Type:        eventType,
+		VenueID:     booking.VenueID.Hex(),
+		Date:        booking.BookingDate.In(loc).Format("2006-01-02"),
+		CourtNumber: booking.CourtNumber,
+		StartTime:   booking.StartTime.In(loc).Format("15:04"),
+		EndTime:     booking.EndTime.In(loc).Format("15:04"),
+	})
+}
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"courtopia-reserve/backend/internal/clock"
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/rbac"
+	"courtopia-reserve/backend/pkg/utils"
+)
+
+// resolveVenue ค้นหาสนามจาก ID ถ้าไม่ได้ระบุและมีสนามเดียวจะใช้สนามนั้น
+func (h *Handler) resolveVenue(ctx context.Context, idStr string) (*models.Venue, error) {
+	if idStr == "" {
+		venues, err := h.venueRepo.FindAll(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(venues) != 1 {
+			return nil, &slotError{http.StatusBadRequest, "venueId is required"}
+		}
+		return venues[0], nil
+	}
+
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, &slotError{http.StatusBadRequest, "Invalid venue ID"}
+	}
+
+	venue, err := h.venueRepo.FindByID(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		return nil, &slotError{http.StatusNotFound, "Venue not found"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return venue, nil
+}
+
+// venueParam คืน venueId จาก URL (/venues/:venueId/...) หรือจาก query string
+func venueParam(c *gin.Context) string {
+	if id := c.Param("venueId"); id != "" {
+		return id
+	}
+	return c.Query("venueId")
+}
+
+// findVenue ค้นหาสนามและตอบ error ให้เองถ้าไม่พบ
+func (h *Handler) findVenue(c *gin.Context, idStr string) (*models.Venue, bool) {
+	venue, err := h.resolveVenue(c.Request.Context(), idStr)
+	var se *slotError
+	if errors.As(err, &se) {
+		c.JSON(se.status, gin.H{"error": se.message})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch venue"})
+		return nil, false
+	}
+	return venue, true
+}
+
+// canAccessVenue ตรวจว่าสิทธิ์ของผู้ใช้ใช้กับสนามนี้ได้หรือไม่
+// ผู้ที่ได้รับมอบหมายสนามจัดการได้เฉพาะสนามนั้น ส่วน venue_admin ที่ยังไม่มีสนามจัดการไม่ได้เลย
+func canAccessVenue(claims *utils.Claims, venueID primitive.ObjectID) bool {
+	if len(claims.Venues) == 0 {
+		return !rbac.VenueScoped(claims.Role)
+	}
+	return slices.Contains(claims.Venues, venueID.Hex())
+}
+
+// requireVenueAccess ตอบ 403 ถ้าผู้ใช้ไม่ได้ดูแลสนามนี้
+func requireVenueAccess(c *gin.Context, venueID primitive.ObjectID) bool {
+	claims := c.MustGet("user").(*utils.Claims)
+	if !canAccessVenue(claims, venueID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not manage this venue"})
+		return false
+	}
+	return true
+}
+
+// findManagedVenue ค้นหาสนามที่ผู้ใช้ดูแลอยู่ และตอบ error ให้เองถ้าไม่พบหรือไม่มีสิทธิ์
+func (h *Handler) findManagedVenue(c *gin.Context, idStr string) (*models.Venue, bool) {
+	if idStr == "" {
+		// ผู้ดูแลสนามเดียวไม่ต้องระบุสนาม
+		if claims := c.MustGet("user").(*utils.Claims); len(claims.Venues) == 1 {
+			idStr = claims.Venues[0]
+		}
+	}
+
+	venue, ok := h.findVenue(c, idStr)
+	if !ok || !requireVenueAccess(c, venue.ID) {
+		return nil, false
+	}
+	return venue, true
+}
+
+// adminVenueScope คืนสนามที่ผู้ใช้ดูข้อมูลได้ (nil = ทุกสนาม) กรองเพิ่มด้วย ?venueId= ได้
+func adminVenueScope(c *gin.Context) ([]primitive.ObjectID, bool) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	if idStr := c.Query("venueId"); idStr != "" {
+		id, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid venue ID"})
+			return nil, false
+		}
+		if !requireVenueAccess(c, id) {
+			return nil, false
+		}
+		return []primitive.ObjectID{id}, true
+	}
+
+	if len(claims.Venues) == 0 {
+		if rbac.VenueScoped(claims.Role) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not manage any venue"})
+			return nil, false
+		}
+		return nil, true
+	}
+
+	venueIDs := make([]primitive.ObjectID, 0, len(claims.Venues))
+	for _, idStr := range claims.Venues {
+		id, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not manage any venue"})
+			return nil, false
+		}
+		venueIDs = append(venueIDs, id)
+	}
+	return venueIDs, true
+}
+
+// venueLocation คืน timezone ของสนาม ใช้ timezone จาก config ถ้าสนามไม่ได้กำหนดหรือโหลดไม่ได้
+func (h *Handler) venueLocation(venue *models.Venue) *time.Location {
+	if venue != nil {
+		if loc, err := clock.LoadLocation(venue.Timezone); err == nil {
+			return loc
+		}
+	}
+	return h.location
+}
+
+// locationOf คืน timezone ของสนามจาก ID
+func (h *Handler) locationOf(ctx context.Context, venueID primitive.ObjectID) (*time.Location, error) {
+	venue, err := h.venueRepo.FindByID(ctx, venueID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	return h.venueLocation(venue), nil
+}
+
+// venueZones คือ timezone ของทุกสนาม ใช้แสดงเวลาของการจองที่มาจากหลายสนาม
+type venueZones struct {
+	byID     map[primitive.ObjectID]*time.Location
+	fallback *time.Location
+}
+
+// of คืน timezone ของสนาม
+func (z venueZones) of(venueID primitive.ObjectID) *time.Location {
+	if loc, ok := z.byID[venueID]; ok {
+		return loc
+	}
+	return z.fallback
+}
+
+// loadVenueZones โหลด timezone ของทุกสนาม
+func (h *Handler) loadVenueZones(ctx context.Context) (venueZones, error) {
+	zones := venueZones{byID: map[primitive.ObjectID]*time.Location{}, fallback: h.location}
+
+	venues, err := h.venueRepo.FindAll(ctx, nil)
+	if err != nil {
+		return zones, err
+	}
+	for _, venue := range venues {
+		zones.byID[venue.ID] = h.venueLocation(venue)
+	}
+	return zones, nil
+}
+
+// validateVenueRequest ตรวจสอบรายละเอียดของสนาม ถ้าไม่ระบุ timezone จะใช้ค่าจาก config
+func (h *Handler) validateVenueRequest(req *models.VenueRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Address = strings.TrimSpace(req.Address)
+	req.Timezone = strings.TrimSpace(req.Timezone)
+	if req.Name == "" {
+		return "Name is required"
+	}
+	if req.Timezone == "" {
+		req.Timezone = h.cfg.Timezone
+	}
+	if _, err := clock.LoadLocation(req.Timezone); err != nil {
+		return "timezone must be an IANA timezone such as Asia/Bangkok"
+	}
+	return ""
+}
+
+// GetVenues ดึงข้อมูลสนามทั้งหมด
+func (h *Handler) GetVenues(c *gin.Context) {
+	venues, err := h.venueRepo.FindAll(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch venues"})
+		return
+	}
+
+	c.JSON(http.StatusOK, venues)
+}
+
+// GetVenue ดึงข้อมูลสนามพร้อมเวลาทำการ
+func (h *Handler) GetVenue(c *gin.Context) {
+	venue, ok := h.findVenue(c, c.Param("venueId"))
+	if !ok {
+		return
+	}
+
+	hours, err := h.settingsRepo.GetOperatingHours(c.Request.Context(), venue.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load operating hours"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.VenueResponse{Venue: venue, OperatingHours: hours})
+}
+
+// GetAdminVenues ดึงข้อมูลสนามที่ผู้ใช้ดูแล
+func (h *Handler) GetAdminVenues(c *gin.Context) {
+	venueIDs, ok := adminVenueScope(c)
+	if !ok {
+		return
+	}
+
+	venues, err := h.venueRepo.FindAll(c.Request.Context(), venueIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch venues"})
+		return
+	}
+
+	c.JSON(http.StatusOK, venues)
+}
+
+// CreateVenue เพิ่มสนามใหม่ เวลาทำการเริ่มต้นเป็นค่าเดียวกับสนามเดิมจนกว่าจะตั้งค่า (admin only)
+func (h *Handler) CreateVenue(c *gin.Context) {
+	var req models.VenueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if msg := h.validateVenueRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	venue := &models.Venue{
+		Name:     req.Name,
+		Address:  req.Address,
+		Timezone: req.Timezone,
+	}
+	if err := h.venueRepo.Create(c.Request.Context(), venue); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create venue"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, venue)
+}
+
+// UpdateVenue แก้ไขชื่อ ที่อยู่ และ timezone ของสนาม (ผู้ดูแลสนาม)
+func (h *Handler) UpdateVenue(c *gin.Context) {
+	venue, ok := h.findManagedVenue(c, c.Param("venueId"))
+	if !ok {
+		return
+	}
+
+	var req models.VenueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if msg := h.validateVenueRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	venue.Name = req.Name
+	venue.Address = req.Address
+	venue.Timezone = req.Timezone
+	if err := h.venueRepo.Update(c.Request.Context(), venue); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update venue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, venue)
+}
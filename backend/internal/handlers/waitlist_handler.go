@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/notify"
+	"courtopia-reserve/backend/internal/rbac"
+	"courtopia-reserve/backend/internal/repository"
+	"courtopia-reserve/backend/internal/webhook"
+	"courtopia-reserve/backend/pkg/utils"
+)
+
+// JoinWaitlist เข้าคิวรอคอร์ทและช่วงเวลาที่ถูกจองเต็ม (ไม่ระบุ courtNumber = คอร์ทใดก็ได้)
+func (h *Handler) JoinWaitlist(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	userID, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.AvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	venue, err := h.resolveVenue(ctx, req.VenueID)
+	if err != nil {
+		respondSlotError(c, err)
+		return
+	}
+
+	var slot *bookingSlot
+	if req.CourtNumber != 0 {
+		slot, err = h.validateBookingSlot(ctx, venue, req.CourtNumber, req.BookingDate, req.StartTime, req.EndTime)
+	} else {
+		slot, err = h.validateSlotTimes(ctx, venue, req.BookingDate, req.StartTime, req.EndTime)
+	}
+	if err != nil {
+		respondSlotError(c, err)
+		return
+	}
+
+	// ผู้ที่จองช่วงเวลานี้ไม่ได้ตามกฎการจองก็เข้าคิวไม่ได้ เพราะจะถูกข้ามเมื่อถึงคิวอยู่ดี
+	if !rbac.Can(claims.Role, rbac.BookingsBypassPolicy) || !canAccessVenue(claims, venue.ID) {
+		if err := h.checkBookingPolicy(ctx, claims.StudentID, req.CourtNumber, slot, primitive.NilObjectID); err != nil {
+			respondSlotError(c, err)
+			return
+		}
+	}
+
+	// ถ้ายังมีคอร์ทว่างให้จองได้ทันที ไม่ต้องเข้าคิว
+	availabilities, err := h.bookingRepo.GetAvailableCourts(ctx, venue.ID, slot.BookingDate, slot.StartTime, slot.EndTime, h.courtRepo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check court availability"})
+		return
+	}
+	for _, availability := range availabilities {
+		if availability.IsAvailable && (req.CourtNumber == 0 || availability.CourtNumber == req.CourtNumber) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A court is available for the selected time, book it directly", "courtNumber": availability.CourtNumber})
+			return
+		}
+	}
+
+	exists, err := h.waitlistRepo.ExistsWaiting(ctx, claims.StudentID, venue.ID, req.CourtNumber, slot.StartTime, slot.EndTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join waitlist"})
+		return
+	}
+	if exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "You are already on the waitlist for this time"})
+		return
+	}
+
+	entry := &models.WaitlistEntry{
+		ID:          primitive.NewObjectID(),
+		UserID:      userID,
+		StudentID:   claims.StudentID,
+		VenueID:     venue.ID,
+		CourtNumber: req.CourtNumber,
+		BookingDate: slot.BookingDate,
+		StartTime:   slot.StartTime,
+		EndTime:     slot.EndTime,
+	}
+	if err := h.waitlistRepo.Create(ctx, entry); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// GetWaitlist ดึงคิวรอของผู้ใช้ที่ยังไม่ถึงเวลา
+func (h *Handler) GetWaitlist(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	entries, err := h.waitlistRepo.FindByStudentID(c.Request.Context(), claims.StudentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// LeaveWaitlist ออกจากคิวรอ
+func (h *Handler) LeaveWaitlist(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid waitlist ID"})
+		return
+	}
+
+	entry, err := h.waitlistRepo.FindByID(c.Request.Context(), id)
+	if err != nil || entry.StudentID != claims.StudentID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Waitlist entry not found"})
+		return
+	}
+
+	if entry.Status != "waiting" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Waitlist entry is no longer waiting"})
+		return
+	}
+
+	if err := h.waitlistRepo.Cancel(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left waitlist successfully"})
+}
+
+// promoteWaitlist จองช่วงเวลาที่ว่างลงจากการยกเลิกให้ผู้ที่รอคิวก่อน แล้วแจ้งทางอีเมลผ่าน outbox
+// ช่วงเวลาที่ว่างอาจรองรับได้หลายคิว ถ้าแต่ละคิวขอเวลาสั้นกว่าและไม่ทับกัน
+// คิวที่ขัดกับกฎการจองในตอนนี้ถูกข้ามและยังรอคิวต่อ
+func (h *Handler) promoteWaitlist(ctx context.Context, freed *models.Booking) {
+	venue, err := h.venueRepo.FindByID(ctx, freed.VenueID)
+	if err != nil {
+		log.Printf("Error fetching venue for booking %s: %v", freed.ID.Hex(), err)
+		return
+	}
+	loc := h.venueLocation(venue)
+
+	entries, err := h.waitlistRepo.FindWaitingForSlot(ctx, freed.VenueID, freed.CourtNumber, freed.StartTime, freed.EndTime)
+	if err != nil {
+		log.Printf("Error fetching waitlist for booking %s: %v", freed.ID.Hex(), err)
+		return
+	}
+
+	for _, entry := range entries {
+		claimed, err := h.waitlistRepo.Claim(ctx, entry.ID)
+		if err != nil {
+			log.Printf("Error claiming waitlist entry %s: %v", entry.ID.Hex(), err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		user, err := h.userRepo.FindByStudentID(ctx, entry.StudentID)
+		if err != nil {
+			log.Printf("Error fetching user %s for waitlist promotion: %v", entry.StudentID, err)
+			_ = h.waitlistRepo.Release(ctx, entry.ID)
+			continue
+		}
+
+		if !rbac.Can(user.Role, rbac.BookingsBypassPolicy) {
+			slot := &bookingSlot{
+				Venue:       venue,
+				BookingDate: entry.BookingDate.In(loc),
+				StartTime:   entry.StartTime.In(loc),
+				EndTime:     entry.EndTime.In(loc),
+			}
+			if err := h.checkBookingPolicy(ctx, entry.StudentID, freed.CourtNumber, slot, primitive.NilObjectID); err != nil {
+				log.Printf("Skipping waitlist entry %s: %v", entry.ID.Hex(), err)
+				_ = h.waitlistRepo.Release(ctx, entry.ID)
+				continue
+			}
+		}
+
+		booking := &models.Booking{
+			ID:          primitive.NewObjectID(),
+			UserID:      entry.UserID,
+			StudentID:   entry.StudentID,
+			VenueID:     freed.VenueID,
+			CourtID:     freed.CourtID,
+			CourtNumber: freed.CourtNumber,
+			BookingDate: entry.BookingDate,
+			StartTime:   entry.StartTime,
+			EndTime:     entry.EndTime,
+			UserEmail:   user.Email,
+		}
+
+		promotion := repository.NewOutboxMessage(notify.TemplateWaitlistPromotion, booking)
+		err = h.bookingRepo.CreateIfAvailable(ctx, booking, promotion)
+		if err != nil {
+			if !errors.Is(err, repository.ErrSlotUnavailable) && !errors.Is(err, repository.ErrCourtUnavailable) {
+				log.Printf("Error booking waitlist entry %s: %v", entry.ID.Hex(), err)
+			}
+			_ = h.waitlistRepo.Release(ctx, entry.ID)
+			continue
+		}
+
+		if err := h.waitlistRepo.MarkBooked(ctx, entry.ID, booking.ID); err != nil {
+			log.Printf("Error updating waitlist entry %s: %v", entry.ID.Hex(), err)
+		}
+
+		log.Printf("Waitlist entry %s promoted to booking %s", entry.ID.Hex(), booking.ID.Hex())
+		h.emitBookingEvent(ctx, webhook.EventBookingCreated, booking)
+	}
+}
+
+// promoteWaitlistAsync เลื่อนคิวรอใน background เพื่อไม่ให้ request ที่ยกเลิกการจองต้องรอ
+func (h *Handler) promoteWaitlistAsync(freed *models.Booking) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		h.promoteWaitlist(ctx, freed)
+	}()
+}
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/webhook"
+	"courtopia-reserve/backend/pkg/utils"
+)
+
+// minWebhookSecretLength กันไม่ให้ตั้ง secret สั้นจนเดาได้
+const minWebhookSecretLength = 16
+
+// emitBookingEvent แจ้งหน้าจองที่เปิดอยู่ และนำเหตุการณ์ของการจองเข้าคิวส่งให้ทุก webhook ที่สมัครรับเหตุการณ์นี้
+// การส่งจริงทำโดย DeliverWebhooks ความผิดพลาดจึงถูก log ไว้เฉยๆ ไม่ทำให้ request ล้ม
+func (h *Handler) emitBookingEvent(ctx context.Context, event string, booking *models.Booking) {
+	h.publishBookingChange(ctx, event, booking)
+
+	webhooks, err := h.webhookRepo.FindSubscribed(ctx, event)
+	if err != nil {
+		log.Printf("Error fetching webhooks for %s: %v", event, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload := models.WebhookEventPayload{
+		ID:         primitive.NewObjectID().Hex(),
+		Event:      event,
+		OccurredAt: h.clock.Now(),
+		Booking:    toWebhookBooking(booking),
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error encoding %s payload for booking %s: %v", event, booking.ID.Hex(), err)
+		return
+	}
+
+	for _, hook := range webhooks {
+		if _, err := h.deliveryRepo.Enqueue(ctx, hook.ID, event, body); err != nil {
+			log.Printf("Error queueing %s for webhook %s: %v", event, hook.ID.Hex(), err)
+		}
+	}
+}
+
+// emitBookingEvents ส่งเหตุการณ์เดียวกันของการจองหลายรายการ
+func (h *Handler) emitBookingEvents(ctx context.Context, event string, bookings []*models.Booking) {
+	for _, booking := range bookings {
+		h.emitBookingEvent(ctx, event, booking)
+	}
+}
+
+func toWebhookBooking(booking *models.Booking) *models.WebhookBooking {
+	data := &models.WebhookBooking{
+		ID:           booking.ID.Hex(),
+		StudentID:    booking.StudentID,
+		VenueID:      booking.VenueID.Hex(),
+		CourtNumber:  booking.CourtNumber,
+		StartTime:    booking.StartTime,
+		EndTime:      booking.EndTime,
+		Status:       booking.Status,
+		Cancellation: booking.Cancellation,
+	}
+	if booking.SeriesID != nil {
+		data.SeriesID = booking.SeriesID.Hex()
+	}
+	return data
+}
+
+// CompleteEndedBookings เปลี่ยนการจองที่หมดเวลาแล้วเป็น completed และแจ้ง webhook
+func (h *Handler) CompleteEndedBookings(ctx context.Context) {
+	completed, err := h.bookingRepo.UpdateCompletedBookings(ctx, h.clock.Now())
+	if err != nil {
+		log.Printf("Error updating completed bookings: %v", err)
+	}
+	h.emitBookingEvents(ctx, webhook.EventBookingCompleted, completed)
+}
+
+// DeliverWebhooks ส่ง payload ที่ถึงเวลาส่งจนกว่าจะหมดคิว
+// endpoint ที่ตอบไม่เป็น 2xx จะถูก retry แบบ exponential backoff และถูกย้ายไปสถานะ dead เมื่อครบจำนวนครั้ง
+func (h *Handler) DeliverWebhooks(ctx context.Context) {
+	for {
+		delivery, err := h.deliveryRepo.ClaimDue(ctx)
+		if err != nil {
+			log.Printf("Error claiming webhook delivery: %v", err)
+			return
+		}
+		if delivery == nil {
+			return
+		}
+
+		attempt := models.WebhookAttempt{At: h.clock.Now()}
+		hook, err := h.webhookRepo.FindByID(ctx, delivery.WebhookID)
+		if err != nil || !hook.IsActive {
+			// webhook ถูกลบหรือปิดไปแล้ว ส่งต่อไปก็ไม่มีประโยชน์
+			if err == nil || errors.Is(err, mongo.ErrNoDocuments) {
+				attempt.Error = "webhook was removed or disabled"
+				if err := h.deliveryRepo.MarkFailed(ctx, delivery, attempt, true); err != nil {
+					log.Printf("Error recording webhook delivery %s: %v", delivery.ID.Hex(), err)
+				}
+			} else {
+				log.Printf("Error fetching webhook %s: %v", delivery.WebhookID.Hex(), err)
+			}
+			continue
+		}
+
+		result, err := h.webhookSender.Send(ctx, hook.URL, hook.Secret, delivery.Event, delivery.ID.Hex(), []byte(delivery.Payload))
+		attempt.StatusCode = result.StatusCode
+		attempt.DurationMs = result.Duration.Milliseconds()
+
+		if err == nil {
+			if err := h.deliveryRepo.MarkDelivered(ctx, delivery.ID, attempt); err != nil {
+				log.Printf("Error recording webhook delivery %s: %v", delivery.ID.Hex(), err)
+			}
+			continue
+		}
+
+		log.Printf("Error delivering %s to webhook %s (attempt %d): %v", delivery.Event, hook.ID.Hex(), delivery.Attempts+1, err)
+		attempt.Error = err.Error()
+		if err := h.deliveryRepo.MarkFailed(ctx, delivery, attempt, false); err != nil {
+			log.Printf("Error recording webhook delivery %s: %v", delivery.ID.Hex(), err)
+		}
+	}
+}
+
+// validateWebhookRequest ตรวจสอบ URL และรายการเหตุการณ์ของ webhook
+func validateWebhookRequest(req *models.WebhookRequest) string {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "URL must be an absolute http or https URL"
+	}
+	if len(req.Events) == 0 {
+		return "At least one event is required"
+	}
+	for _, event := range req.Events {
+		if !webhook.ValidEvent(event) {
+			return "Unknown event: " + event
+		}
+	}
+	if req.Secret != "" && len(req.Secret) < minWebhookSecretLength {
+		return "Secret must be at least 16 characters"
+	}
+	return ""
+}
+
+// GetWebhooks แสดงรายการ webhook ทั้งหมด (admin only)
+func (h *Handler) GetWebhooks(c *gin.Context) {
+	webhooks, err := h.webhookRepo.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks, "events": webhook.Events})
+}
+
+// CreateWebhook ลงทะเบียน webhook ใหม่ (admin only)
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	claims := c.MustGet("user").(*utils.Claims)
+
+	var req models.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if msg := validateWebhookRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if req.Secret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Secret is required"})
+		return
+	}
+
+	hook := &models.Webhook{
+		URL:         req.URL,
+		Events:      req.Events,
+		Secret:      req.Secret,
+		Description: req.Description,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		CreatedBy:   claims.StudentID,
+	}
+	if err := h.webhookRepo.Create(c.Request.Context(), hook); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, hook)
+}
+
+// UpdateWebhook แก้ไข URL เหตุการณ์ secret หรือสถานะของ webhook (admin only)
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	hook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	var req models.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if msg := validateWebhookRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	hook.URL = req.URL
+	hook.Events = req.Events
+	hook.Description = req.Description
+	if req.Secret != "" {
+		hook.Secret = req.Secret
+	}
+	if req.IsActive != nil {
+		hook.IsActive = *req.IsActive
+	}
+
+	if err := h.webhookRepo.Update(c.Request.Context(), hook); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hook)
+}
+
+// DeleteWebhook ลบ webhook และยกเลิก payload ที่ยังไม่ได้ส่ง (admin only)
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	deleted, err := h.webhookRepo.Delete(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	if err := h.deliveryRepo.DeletePending(c.Request.Context(), id); err != nil {
+		log.Printf("Error dropping pending deliveries for webhook %s: %v", id.Hex(), err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// TestWebhook ส่งเหตุการณ์ ping ไปยัง webhook เพื่อทดสอบ URL และ secret (admin only)
+func (h *Handler) TestWebhook(c *gin.Context) {
+	hook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	body, _ := json.Marshal(models.WebhookEventPayload{
+		ID:         primitive.NewObjectID().Hex(),
+		Event:      webhook.EventPing,
+		OccurredAt: h.clock.Now(),
+	})
+	delivery, err := h.deliveryRepo.Enqueue(c.Request.Context(), hook.ID, webhook.EventPing, body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue test event"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// GetWebhookDeliveries แสดงประวัติการส่งของ webhook (admin only)
+func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
+	hook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", "pending", "sending", "delivered", "dead":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.deliveryRepo.FindByWebhookID(c.Request.Context(), hook.ID, status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// RetryWebhookDelivery นำ payload ที่อยู่ในสถานะ dead กลับเข้าคิวส่งอีกครั้ง (admin only)
+func (h *Handler) RetryWebhookDelivery(c *gin.Context) {
+	hook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := primitive.ObjectIDFromHex(c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	retried, err := h.deliveryRepo.Retry(c.Request.Context(), hook.ID, deliveryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry delivery"})
+		return
+	}
+	if !retried {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead delivery not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery queued for retry"})
+}
+
+// findWebhook ดึง webhook จาก :id ใน URL และตอบ error ให้เองถ้าไม่พบ
+func (h *Handler) findWebhook(c *gin.Context) (*models.Webhook, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return nil, false
+	}
+
+	hook, err := h.webhookRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return nil, false
+	}
+
+	return hook, true
+}
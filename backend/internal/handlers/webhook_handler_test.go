@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"courtopia-reserve/backend/internal/clock"
+	"courtopia-reserve/backend/internal/models"
+	"courtopia-reserve/backend/internal/repository"
+	"courtopia-reserve/backend/internal/webhook"
+)
+
+const testWebhookSecret = "0123456789abcdef"
+
+// webhookEndpoint คือปลายทาง webhook จำลองที่ตอบด้วย status ที่กำหนดและนับจำนวนครั้งที่ถูกเรียก
+type webhookEndpoint struct {
+	server   *httptest.Server
+	status   atomic.Int32
+	calls    atomic.Int32
+	verified atomic.Int32
+}
+
+func newWebhookEndpoint(t *testing.T, status int) *webhookEndpoint {
+	t.Helper()
+	e := &webhookEndpoint{}
+	e.status.Store(int32(status))
+	e.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		// timestamp ของลายเซ็นเป็นเวลาจริง จึงตรวจกับ time.Now ไม่ใช่ clock ของ Handler
+		if webhook.Verify(testWebhookSecret, r.Header.Get(webhook.HeaderTimestamp), r.Header.Get(webhook.HeaderSignature), body, time.Now()) {
+			e.verified.Add(1)
+		}
+		w.WriteHeader(int(e.status.Load()))
+	}))
+	t.Cleanup(e.server.Close)
+	return e
+}
+
+// newTestDelivery ลงทะเบียน webhook ที่ชี้ไปยัง endpoint และเข้าคิว payload หนึ่งรายการ
+func newTestDelivery(t *testing.T, h *Handler, endpoint *webhookEndpoint) *models.WebhookDelivery {
+	t.Helper()
+	ctx := context.Background()
+
+	hook := &models.Webhook{
+		URL:       endpoint.server.URL,
+		Events:    []string{webhook.EventBookingCreated},
+		Secret:    testWebhookSecret,
+		IsActive:  true,
+		CreatedBy: "admin",
+	}
+	if err := h.webhookRepo.Create(ctx, hook); err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+
+	delivery, err := h.deliveryRepo.Enqueue(ctx, hook.ID, webhook.EventBookingCreated, []byte(`{"event":"booking.created"}`))
+	if err != nil {
+		t.Fatalf("enqueue delivery: %v", err)
+	}
+	return delivery
+}
+
+func findTestDelivery(t *testing.T, db *mongo.Database, id primitive.ObjectID) *models.WebhookDelivery {
+	t.Helper()
+	var delivery models.WebhookDelivery
+	if err := db.Collection("webhook_deliveries").FindOne(context.Background(), bson.M{"_id": id}).Decode(&delivery); err != nil {
+		t.Fatalf("find delivery: %v", err)
+	}
+	return &delivery
+}
+
+func TestDeliverWebhooksSuccess(t *testing.T) {
+	h, db := newTestHandler(t)
+	now := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+	h.UseClock(clock.Fixed(now))
+
+	endpoint := newWebhookEndpoint(t, http.StatusOK)
+	queued := newTestDelivery(t, h, endpoint)
+
+	h.DeliverWebhooks(context.Background())
+
+	if got := endpoint.calls.Load(); got != 1 {
+		t.Fatalf("endpoint called %d times, want 1", got)
+	}
+	if got := endpoint.verified.Load(); got != 1 {
+		t.Errorf("%d requests carried a valid signature, want 1", got)
+	}
+
+	delivery := findTestDelivery(t, db, queued.ID)
+	if delivery.Status != "delivered" {
+		t.Errorf("got status %q, want delivered", delivery.Status)
+	}
+	if delivery.Attempts != 1 || len(delivery.History) != 1 {
+		t.Errorf("got %d attempts and %d history entries, want 1 and 1", delivery.Attempts, len(delivery.History))
+	}
+	if delivery.DeliveredAt == nil || !delivery.DeliveredAt.Equal(now) {
+		t.Errorf("got delivered at %v, want %v", delivery.DeliveredAt, now)
+	}
+	if delivery.History[0].StatusCode != http.StatusOK {
+		t.Errorf("got recorded status %d, want %d", delivery.History[0].StatusCode, http.StatusOK)
+	}
+}
+
+// TestDeliverWebhooksRetryAndDeadLetter ตรวจว่า 5xx ถูกส่งซ้ำตาม backoff ที่เพิ่มขึ้นเรื่อยๆ
+// และถูกย้ายไปสถานะ dead เมื่อครบจำนวนครั้งสูงสุด
+func TestDeliverWebhooksRetryAndDeadLetter(t *testing.T) {
+	h, db := newTestHandler(t)
+	ctx := context.Background()
+	now := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+	h.UseClock(clock.Fixed(now))
+
+	endpoint := newWebhookEndpoint(t, http.StatusServiceUnavailable)
+	queued := newTestDelivery(t, h, endpoint)
+
+	for attempt := 1; attempt < queued.MaxAttempts; attempt++ {
+		h.DeliverWebhooks(ctx)
+
+		delivery := findTestDelivery(t, db, queued.ID)
+		if delivery.Status != "pending" {
+			t.Fatalf("attempt %d: got status %q, want pending", attempt, delivery.Status)
+		}
+		if delivery.Attempts != attempt {
+			t.Fatalf("attempt %d: got %d recorded attempts", attempt, delivery.Attempts)
+		}
+		wantNext := now.Add(repository.OutboxBackoff(attempt))
+		if !delivery.NextAttemptAt.Equal(wantNext) {
+			t.Fatalf("attempt %d: next attempt at %v, want %v", attempt, delivery.NextAttemptAt, wantNext)
+		}
+		if got := delivery.History[attempt-1].StatusCode; got != http.StatusServiceUnavailable {
+			t.Errorf("attempt %d: got recorded status %d, want %d", attempt, got, http.StatusServiceUnavailable)
+		}
+
+		// ยังไม่ถึงเวลา retry จึงต้องไม่ส่งซ้ำ
+		h.DeliverWebhooks(ctx)
+		if got := endpoint.calls.Load(); got != int32(attempt) {
+			t.Fatalf("attempt %d: endpoint called %d times before the backoff elapsed", attempt, got)
+		}
+
+		now = wantNext
+		h.UseClock(clock.Fixed(now))
+	}
+
+	// ครั้งสุดท้ายล้มเหลวอีกจึงถูกย้ายไป dead
+	h.DeliverWebhooks(ctx)
+
+	delivery := findTestDelivery(t, db, queued.ID)
+	if delivery.Status != "dead" {
+		t.Fatalf("got status %q after %d attempts, want dead", delivery.Status, delivery.Attempts)
+	}
+	if delivery.Attempts != queued.MaxAttempts || len(delivery.History) != queued.MaxAttempts {
+		t.Errorf("got %d attempts and %d history entries, want %d", delivery.Attempts, len(delivery.History), queued.MaxAttempts)
+	}
+	if delivery.LastError == "" {
+		t.Error("last error was not recorded")
+	}
+
+	// payload ที่ dead แล้วไม่ถูกส่งอีกแม้ endpoint จะกลับมาใช้ได้
+	endpoint.status.Store(http.StatusOK)
+	h.UseClock(clock.Fixed(now.Add(24 * time.Hour)))
+	h.DeliverWebhooks(ctx)
+	if got := endpoint.calls.Load(); got != int32(queued.MaxAttempts) {
+		t.Errorf("endpoint called %d times, want %d", got, queued.MaxAttempts)
+	}
+}
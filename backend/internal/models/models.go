@@ -8,41 +8,300 @@ import (
 
 // User represents a user in the system
 type User struct {
+	ID                 primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	StudentID          string               `bson:"student_id" json:"studentId"` // ใช้เป็น username ในการ login
+	Password           string               `bson:"password" json:"-"`           // ไม่ส่ง password กลับไป
+	Name               string               `bson:"name" json:"name"`
+	Email              string               `bson:"email,omitempty" json:"email,omitempty"`                             // optional
+	Role               string               `bson:"role" json:"role"`                                                   // student, club_manager, staff, admin
+	ProfilePicture     string               `bson:"profile_picture,omitempty" json:"profilePicture,omitempty"`          // URL ของรูปโปรไฟล์
+	Language           string               `bson:"language,omitempty" json:"language,omitempty"`                       // ภาษาของอีเมล: th, en
+	BookingBannedUntil *time.Time           `bson:"booking_banned_until,omitempty" json:"bookingBannedUntil,omitempty"` // ห้ามจองจนถึงเวลานี้เพราะไม่มาใช้คอร์ทบ่อยเกินไป
+	CalendarToken      string               `bson:"calendar_token,omitempty" json:"-"`                                  // token ลับใน URL ของ calendar feed
+	EmailVerifiedAt    *time.Time           `bson:"email_verified_at,omitempty" json:"emailVerifiedAt,omitempty"`       // ว่าง = ยังไม่ได้ยืนยันอีเมล
+	VenueIDs           []primitive.ObjectID `bson:"venue_ids,omitempty" json:"venueIds,omitempty"`                      // สนามที่ดูแล ว่าง = ทุกสนาม (ยกเว้น venue_admin)
+	CreatedAt          time.Time            `bson:"created_at" json:"createdAt"`
+	UpdatedAt          time.Time            `bson:"updated_at" json:"updatedAt"`
+}
+
+// ActionToken represents a single-use token emailed to a user, such as an email verification link
+type ActionToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"userId"`
+	Purpose   string             `bson:"purpose" json:"purpose"` // verify_email, reset_password
+	TokenHash string             `bson:"token_hash" json:"-"`
+	Email     string             `bson:"email" json:"email"`                              // อีเมลที่ส่ง token ไปให้
+	IPAddress string             `bson:"ip_address,omitempty" json:"ipAddress,omitempty"` // IP ที่ขอให้ส่งอีเมล
+	ExpiresAt time.Time          `bson:"expires_at" json:"expiresAt"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty" json:"usedAt,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// Session represents a login on one device, identified by its rotating refresh token
+type Session struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID           primitive.ObjectID `bson:"user_id" json:"userId"`
+	StudentID        string             `bson:"student_id" json:"studentId"`
+	RefreshTokenHash string             `bson:"refresh_token_hash" json:"-"` // เก็บเฉพาะ hash ของ refresh token
+	UserAgent        string             `bson:"user_agent,omitempty" json:"userAgent,omitempty"`
+	IPAddress        string             `bson:"ip_address,omitempty" json:"ipAddress,omitempty"`
+	CreatedAt        time.Time          `bson:"created_at" json:"createdAt"`
+	LastUsedAt       time.Time          `bson:"last_used_at" json:"lastUsedAt"`
+	ExpiresAt        time.Time          `bson:"expires_at" json:"expiresAt"`
+	RevokedAt        *time.Time         `bson:"revoked_at,omitempty" json:"revokedAt,omitempty"`
+	RevokedReason    string             `bson:"revoked_reason,omitempty" json:"revokedReason,omitempty"` // logout, logout_all, refresh_reuse, password_reset, role_changed
+}
+
+// LoginThrottle tracks failed logins for one account or one IP address
+type LoginThrottle struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Kind          string             `bson:"kind" json:"kind"`         // account, ip
+	Subject       string             `bson:"subject" json:"subject"`   // รหัสนักศึกษา หรือ IP address
+	Failures      int                `bson:"failures" json:"failures"` // นับใหม่หลังถูกล็อกแต่ละครั้ง
+	Lockouts      int                `bson:"lockouts" json:"lockouts"`
+	LastFailureAt time.Time          `bson:"last_failure_at" json:"lastFailureAt"`
+	NextAttemptAt *time.Time         `bson:"next_attempt_at,omitempty" json:"nextAttemptAt,omitempty"`
+	LockedUntil   *time.Time         `bson:"locked_until,omitempty" json:"lockedUntil,omitempty"`
+	ExpiresAt     time.Time          `bson:"expires_at" json:"expiresAt"`
+	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// LoginAttempt is an audit record of one login attempt
+type LoginAttempt struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	StudentID string              `bson:"student_id" json:"studentId"`
+	UserID    *primitive.ObjectID `bson:"user_id,omitempty" json:"userId,omitempty"`
+	IPAddress string              `bson:"ip_address" json:"ipAddress"`
+	UserAgent string              `bson:"user_agent,omitempty" json:"userAgent,omitempty"`
+	Success   bool                `bson:"success" json:"success"`
+	Reason    string              `bson:"reason" json:"reason"` // success, unknown_user, invalid_password, throttled, locked
+	CreatedAt time.Time           `bson:"created_at" json:"createdAt"`
+}
+
+// Venue represents a sports building that contains courts
+type Venue struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	StudentID string             `bson:"student_id" json:"studentId"` // ใช้เป็น username ในการ login
-	Password  string             `bson:"password" json:"-"`           // ไม่ส่ง password กลับไป
 	Name      string             `bson:"name" json:"name"`
-	Email     string             `bson:"email,omitempty" json:"email,omitempty"` // optional
-	Role      string             `bson:"role" json:"role"`                       // user, admin
-	ProfilePicture string        `bson:"profile_picture,omitempty" json:"profilePicture,omitempty"` // URL ของรูปโปรไฟล์
+	Address   string             `bson:"address,omitempty" json:"address,omitempty"`
+	Timezone  string             `bson:"timezone" json:"timezone"` // IANA timezone เช่น Asia/Bangkok
 	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
 	UpdatedAt time.Time          `bson:"updated_at" json:"updatedAt"`
 }
 
 // Court represents a badminton court
 type Court struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	CourtNumber int                `bson:"court_number" json:"courtNumber"` // เลขคอร์ท 1-6
-	Name        string             `bson:"name" json:"name"`
-	IsActive    bool               `bson:"is_active" json:"isActive"`                    // สถานะว่าใช้งานได้หรือไม่
-	Location    string             `bson:"location,omitempty" json:"location,omitempty"` // optional
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	VenueID      primitive.ObjectID `bson:"venue_id" json:"venueId"`
+	CourtNumber  int                `bson:"court_number" json:"courtNumber"` // เลขคอร์ท ไม่ซ้ำกันภายในสนาม และเปลี่ยนไม่ได้เพราะการจองอ้างอิงเลขนี้
+	Name         string             `bson:"name" json:"name"`
+	IsActive     bool               `bson:"is_active" json:"isActive"`                           // สถานะว่าใช้งานได้หรือไม่
+	Location     string             `bson:"location,omitempty" json:"location,omitempty"`        // optional
+	SurfaceType  string             `bson:"surface_type,omitempty" json:"surfaceType,omitempty"` // wood, synthetic, concrete
+	Capacity     int                `bson:"capacity,omitempty" json:"capacity,omitempty"`        // จำนวนผู้เล่นสูงสุด
+	ArchivedAt   *time.Time         `bson:"archived_at,omitempty" json:"archivedAt,omitempty"`   // คอร์ทที่เลิกใช้แล้ว ไม่แสดงและจองไม่ได้
+	LastBookedAt *time.Time         `bson:"last_booked_at,omitempty" json:"-"`                   // เขียนทุกครั้งที่มีการจอง เพื่อให้การจองและการเลิกใช้คอร์ทที่เกิดพร้อมกันชนกัน
+	CreatedAt    time.Time          `bson:"created_at,omitempty" json:"createdAt,omitzero"`
+	UpdatedAt    time.Time          `bson:"updated_at,omitempty" json:"updatedAt,omitzero"`
 }
 
 // Booking represents a court booking
 type Booking struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	UserID      primitive.ObjectID `bson:"user_id" json:"userId"`
-	StudentID   string             `bson:"student_id" json:"studentId"` // เก็บ StudentID ไว้ด้วยเพื่อง่ายต่อการค้นหา
+	ID               primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID           primitive.ObjectID   `bson:"user_id" json:"userId"`
+	StudentID        string               `bson:"student_id" json:"studentId"` // เก็บ StudentID ไว้ด้วยเพื่อง่ายต่อการค้นหา
+	VenueID          primitive.ObjectID   `bson:"venue_id" json:"venueId"`
+	CourtID          primitive.ObjectID   `bson:"court_id" json:"courtId"`
+	CourtNumber      int                  `bson:"court_number" json:"courtNumber"` // เก็บเลขคอร์ทไว้ด้วยเพื่อความสะดวก
+	BookingDate      time.Time            `bson:"booking_date" json:"bookingDate"` // วันที่จอง
+	StartTime        time.Time            `bson:"start_time" json:"startTime"`     // เวลาเริ่มใช้คอร์ท
+	EndTime          time.Time            `bson:"end_time" json:"endTime"`         // เวลาสิ้นสุด (ไม่เกิน 2 ชั่วโมงจากเวลาเริ่ม)
+	Status           string               `bson:"status" json:"status"`            // active, cancelled, completed, no_show
+	CheckedInAt      *time.Time           `bson:"checked_in_at,omitempty" json:"checkedInAt,omitempty"`
+	CheckedInBy      string               `bson:"checked_in_by,omitempty" json:"checkedInBy,omitempty"` // StudentID ของผู้ที่เช็กอินให้
+	CreatedAt        time.Time            `bson:"created_at" json:"createdAt"`
+	UpdatedAt        time.Time            `bson:"updated_at" json:"updatedAt"`
+	NotificationSent bool                 `bson:"notification_sent"`
+	UserEmail        string               `bson:"user_email" json:"userEmail"`
+	SeriesID         *primitive.ObjectID  `bson:"series_id,omitempty" json:"seriesId,omitempty"`        // มีค่าเมื่อเป็นส่วนหนึ่งของการจองแบบประจำ
+	Changes          []BookingChange      `bson:"changes,omitempty" json:"changes,omitempty"`           // ประวัติการย้ายคอร์ทหรือเวลา
+	Cancellation     *BookingCancellation `bson:"cancellation,omitempty" json:"cancellation,omitempty"` // มีค่าเมื่อถูกยกเลิก
+}
+
+// BookingPlacement is the court and time a booking occupies
+type BookingPlacement struct {
 	CourtID     primitive.ObjectID `bson:"court_id" json:"courtId"`
-	CourtNumber int                `bson:"court_number" json:"courtNumber"` // เก็บเลขคอร์ทไว้ด้วยเพื่อความสะดวก
-	BookingDate time.Time          `bson:"booking_date" json:"bookingDate"` // วันที่จอง
-	StartTime   time.Time          `bson:"start_time" json:"startTime"`     // เวลาเริ่มใช้คอร์ท
-	EndTime     time.Time          `bson:"end_time" json:"endTime"`         // เวลาสิ้นสุด (ไม่เกิน 2 ชั่วโมงจากเวลาเริ่ม)
-	Status      string             `bson:"status" json:"status"`            // active, cancelled
+	CourtNumber int                `bson:"court_number" json:"courtNumber"`
+	BookingDate time.Time          `bson:"booking_date" json:"bookingDate"`
+	StartTime   time.Time          `bson:"start_time" json:"startTime"`
+	EndTime     time.Time          `bson:"end_time" json:"endTime"`
+}
+
+// BookingChange records one move of a booking to another court or time
+type BookingChange struct {
+	From      BookingPlacement `bson:"from" json:"from"`
+	To        BookingPlacement `bson:"to" json:"to"`
+	ChangedBy string           `bson:"changed_by" json:"changedBy"` // StudentID ของผู้ที่ย้ายการจอง
+	ChangedAt time.Time        `bson:"changed_at" json:"changedAt"`
+}
+
+// BookingCancellation records who cancelled a booking, when and why
+type BookingCancellation struct {
+	CancelledBy    string    `bson:"cancelled_by" json:"cancelledBy"` // StudentID ของผู้ที่ยกเลิก
+	CancelledAt    time.Time `bson:"cancelled_at" json:"cancelledAt"`
+	Reason         string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	Late           bool      `bson:"late,omitempty" json:"late,omitempty"`                      // ยกเลิกหลังเวลาที่ยกเลิกได้ฟรี นับรวมกับการไม่มาใช้คอร์ท
+	BypassedPolicy bool      `bson:"bypassed_policy,omitempty" json:"bypassedPolicy,omitempty"` // ผู้ดูแลยกเลิกโดยไม่ใช้กฎการยกเลิก
+}
+
+// BookingSeries represents a weekly recurring booking that generates Booking occurrences
+type BookingSeries struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID        primitive.ObjectID `bson:"user_id" json:"userId"`
+	StudentID     string             `bson:"student_id" json:"studentId"`
+	VenueID       primitive.ObjectID `bson:"venue_id" json:"venueId"`
+	CourtID       primitive.ObjectID `bson:"court_id" json:"courtId"`
+	CourtNumber   int                `bson:"court_number" json:"courtNumber"`
+	Weekdays      []int              `bson:"weekdays" json:"weekdays"`            // 0 = อาทิตย์ ... 6 = เสาร์
+	IntervalWeeks int                `bson:"interval_weeks" json:"intervalWeeks"` // ทุกกี่สัปดาห์
+	StartDate     time.Time          `bson:"start_date" json:"startDate"`
+	Until         *time.Time         `bson:"until,omitempty" json:"until,omitempty"` // วันสุดท้าย (รวมวันนั้น)
+	Count         int                `bson:"count,omitempty" json:"count,omitempty"` // จำนวนครั้ง ถ้าไม่ได้กำหนด Until
+	StartTime     string             `bson:"start_time" json:"startTime"`            // Format: HH:MM
+	EndTime       string             `bson:"end_time" json:"endTime"`                // Format: HH:MM
+	Status        string             `bson:"status" json:"status"`                   // active, cancelled
+	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// Blackout represents a period when courts are out of service, e.g. for maintenance
+type Blackout struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	VenueID      primitive.ObjectID `bson:"venue_id" json:"venueId"`
+	CourtNumbers []int              `bson:"court_numbers" json:"courtNumbers"`
+	StartTime    time.Time          `bson:"start_time" json:"startTime"`
+	EndTime      time.Time          `bson:"end_time" json:"endTime"`
+	Reason       string             `bson:"reason" json:"reason"`
+	CreatedBy    string             `bson:"created_by" json:"createdBy"` // StudentID ของ admin ที่สร้าง
+	CreatedAt    time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// WaitlistEntry represents a user waiting for a court and time window to become free
+type WaitlistEntry struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID      primitive.ObjectID  `bson:"user_id" json:"userId"`
+	StudentID   string              `bson:"student_id" json:"studentId"`
+	VenueID     primitive.ObjectID  `bson:"venue_id" json:"venueId"`
+	CourtNumber int                 `bson:"court_number" json:"courtNumber"` // 0 = คอร์ทใดก็ได้ในสนาม
+	BookingDate time.Time           `bson:"booking_date" json:"bookingDate"`
+	StartTime   time.Time           `bson:"start_time" json:"startTime"`
+	EndTime     time.Time           `bson:"end_time" json:"endTime"`
+	Status      string              `bson:"status" json:"status"`                            // waiting, promoting, booked, cancelled
+	BookingID   *primitive.ObjectID `bson:"booking_id,omitempty" json:"bookingId,omitempty"` // การจองที่ได้รับเมื่อถูกเลื่อนขึ้น
+	CreatedAt   time.Time           `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time           `bson:"updated_at" json:"updatedAt"`
+}
+
+// DeliveryAttempt represents one try at delivering an outbox message
+type DeliveryAttempt struct {
+	At    time.Time `bson:"at" json:"at"`
+	Error string    `bson:"error,omitempty" json:"error,omitempty"` // ว่างเมื่อส่งสำเร็จ
+}
+
+// OutboxMessage represents a notification waiting to be delivered, with its delivery history
+type OutboxMessage struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Template      string             `bson:"template" json:"template"`                        // booking_confirmation, reminder, verify_email, ...
+	BookingID     primitive.ObjectID `bson:"booking_id,omitempty" json:"bookingId,omitempty"` // ว่างสำหรับอีเมลเกี่ยวกับบัญชี
+	StudentID     string             `bson:"student_id" json:"studentId"`
+	Data          map[string]string  `bson:"data,omitempty" json:"-"` // เช่นลิงก์ที่มี token ลบทิ้งเมื่อส่งเสร็จ และไม่แสดงให้เจ้าหน้าที่เห็น
+	Status        string             `bson:"status" json:"status"`    // pending, sending, sent, dead
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	MaxAttempts   int                `bson:"max_attempts" json:"maxAttempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"nextAttemptAt"`
+	LockedUntil   time.Time          `bson:"locked_until,omitempty" json:"-"`
+	LastError     string             `bson:"last_error,omitempty" json:"lastError,omitempty"`
+	History       []DeliveryAttempt  `bson:"history" json:"history"`
+	SentAt        *time.Time         `bson:"sent_at,omitempty" json:"sentAt,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// Webhook represents an endpoint that receives booking events
+type Webhook struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	URL         string             `bson:"url" json:"url"`
+	Events      []string           `bson:"events" json:"events"` // เช่น booking.created, booking.cancelled
+	Secret      string             `bson:"secret" json:"-"`      // ใช้ลงลายเซ็น HMAC ไม่ส่งกลับไปให้ client
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	IsActive    bool               `bson:"is_active" json:"isActive"`
+	CreatedBy   string             `bson:"created_by" json:"createdBy"`
 	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
 	UpdatedAt   time.Time          `bson:"updated_at" json:"updatedAt"`
-	NotificationSent bool `bson:"notification_sent"`
-	UserEmail        string             `bson:"user_email" json:"userEmail"`
+}
+
+// WebhookAttempt represents one try at delivering a webhook payload
+type WebhookAttempt struct {
+	At         time.Time `bson:"at" json:"at"`
+	StatusCode int       `bson:"status_code,omitempty" json:"statusCode,omitempty"`
+	DurationMs int64     `bson:"duration_ms" json:"durationMs"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"` // ว่างเมื่อส่งสำเร็จ
+}
+
+// WebhookDelivery represents one event queued for one webhook, with its delivery log
+type WebhookDelivery struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	WebhookID     primitive.ObjectID `bson:"webhook_id" json:"webhookId"`
+	Event         string             `bson:"event" json:"event"`
+	Payload       string             `bson:"payload" json:"payload"` // JSON ที่ส่งจริง เก็บไว้เพื่อให้ retry ส่งข้อมูลเดิม
+	Status        string             `bson:"status" json:"status"`   // pending, sending, delivered, dead
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	MaxAttempts   int                `bson:"max_attempts" json:"maxAttempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"nextAttemptAt"`
+	LockedUntil   time.Time          `bson:"locked_until,omitempty" json:"-"`
+	LastError     string             `bson:"last_error,omitempty" json:"lastError,omitempty"`
+	History       []WebhookAttempt   `bson:"history" json:"history"`
+	DeliveredAt   *time.Time         `bson:"delivered_at,omitempty" json:"deliveredAt,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// DayHours represents the opening hours of a venue on one weekday
+type DayHours struct {
+	Weekday   int    `bson:"weekday" json:"weekday"` // 0 = อาทิตย์ ... 6 = เสาร์
+	IsClosed  bool   `bson:"is_closed" json:"isClosed"`
+	OpenTime  string `bson:"open_time" json:"openTime"`   // Format: HH:MM
+	CloseTime string `bson:"close_time" json:"closeTime"` // Format: HH:MM
+}
+
+// OperatingHours represents a venue's opening hours and booking slot rules
+type OperatingHours struct {
+	ID                 string     `bson:"_id" json:"-"`
+	Days               []DayHours `bson:"days" json:"days" binding:"required"`
+	SlotMinutes        int        `bson:"slot_minutes" json:"slotMinutes" binding:"required"`                // เวลาเริ่มและสิ้นสุดต้องตรงกับช่วงนี้ เช่น ทุก 30 นาที
+	MinDurationMinutes int        `bson:"min_duration_minutes" json:"minDurationMinutes" binding:"required"` // ระยะเวลาจองขั้นต่ำ
+	UpdatedAt          time.Time  `bson:"updated_at" json:"updatedAt"`
+}
+
+// BookingPolicy represents the fair-use limits applied when a user books a court.
+// A limit of 0 means unlimited.
+type BookingPolicy struct {
+	ID                string `bson:"_id" json:"-"`
+	MaxActiveBookings int    `bson:"max_active_bookings" json:"maxActiveBookings"` // จำนวนการจองที่ยังไม่ถึงเวลาได้สูงสุด
+	MaxHoursPerDay    int    `bson:"max_hours_per_day" json:"maxHoursPerDay"`
+	MaxHoursPerWeek   int    `bson:"max_hours_per_week" json:"maxHoursPerWeek"` // สัปดาห์เริ่มวันจันทร์
+	MaxDaysAhead      int    `bson:"max_days_ahead" json:"maxDaysAhead"`        // จองล่วงหน้าได้ไม่เกินกี่วัน
+	NoBackToBack      bool   `bson:"no_back_to_back" json:"noBackToBack"`       // ห้ามจองคอร์ทเดิมต่อเนื่องกัน
+
+	CheckInOpensMinutes int `bson:"check_in_opens_minutes" json:"checkInOpensMinutes"` // เช็กอินได้ก่อนเวลาเริ่มกี่นาที
+	CheckInGraceMinutes int `bson:"check_in_grace_minutes" json:"checkInGraceMinutes"` // ไม่เช็กอินภายในกี่นาทีหลังเวลาเริ่มถือว่าไม่มา (0 = ไม่ติดตาม)
+	NoShowLimit         int `bson:"no_show_limit" json:"noShowLimit"`                  // ไม่มากี่ครั้งภายใน NoShowWindowDays แล้วถูกห้ามจอง
+	NoShowWindowDays    int `bson:"no_show_window_days" json:"noShowWindowDays"`
+	NoShowBanDays       int `bson:"no_show_ban_days" json:"noShowBanDays"`
+
+	UnverifiedMaxActiveBookings int `bson:"unverified_max_active_bookings" json:"unverifiedMaxActiveBookings"` // จำนวนการจองของผู้ใช้ที่ยังไม่ยืนยันอีเมล (0 = ไม่จำกัดเพิ่ม)
+
+	CancelCutoffHours int       `bson:"cancel_cutoff_hours" json:"cancelCutoffHours"` // ยกเลิกฟรีได้ถึงกี่ชั่วโมงก่อนเวลาเริ่ม หลังจากนั้นนับเป็นการยกเลิกช้า (0 = ไม่มีการยกเลิกช้า)
+	UpdatedAt         time.Time `bson:"updated_at" json:"updatedAt"`
 }
 
 // DTO objects (Data Transfer Objects) for requests and responses
@@ -53,6 +312,7 @@ type RegisterRequest struct {
 	Password  string `json:"password" binding:"required"`
 	Name      string `json:"name" binding:"required"`
 	Email     string `json:"email,omitempty"`
+	Language  string `json:"language,omitempty"` // th, en
 }
 
 // LoginRequest represents the data needed for user login
@@ -63,49 +323,257 @@ type LoginRequest struct {
 
 // LoginResponse represents the data returned after successful login
 type LoginResponse struct {
-	Token     string `json:"token"`
+	Token        string `json:"token"`        // access token อายุสั้น
+	RefreshToken string `json:"refreshToken"` // ใช้ขอ access token ใหม่ ใช้ได้ครั้งเดียว
+	ExpiresIn    int    `json:"expiresIn"`    // อายุของ access token เป็นวินาที
+	StudentID    string `json:"studentId"`
+	Name         string `json:"name"`
+	Role         string `json:"role"`
+}
+
+// VerifyEmailRequest represents the data needed to verify an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ForgotPasswordRequest represents a request for a password reset email.
+// Either the student ID or the email address identifies the account.
+type ForgotPasswordRequest struct {
 	StudentID string `json:"studentId"`
-	Name      string `json:"name"`
-	Role      string `json:"role"`
+	Email     string `json:"email"`
+}
+
+// ResetPasswordRequest represents the data needed to choose a new password
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshRequest represents the data needed to refresh or revoke a session
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
 }
 
 // BookingRequest represents the data needed to create a booking
 type BookingRequest struct {
+	VenueID     string `json:"venueId,omitempty"` // ไม่ต้องระบุถ้ามีสนามเดียว
 	CourtNumber int    `json:"courtNumber" binding:"required"`
 	BookingDate string `json:"bookingDate" binding:"required"` // Format: YYYY-MM-DD
 	StartTime   string `json:"startTime" binding:"required"`   // Format: HH:MM
 	EndTime     string `json:"endTime" binding:"required"`     // Format: HH:MM
 }
 
+// RescheduleRequest represents the new court and time of a booking.
+// Fields that are left out keep their current value.
+type RescheduleRequest struct {
+	CourtNumber int    `json:"courtNumber,omitempty"`
+	BookingDate string `json:"bookingDate,omitempty"` // Format: YYYY-MM-DD
+	StartTime   string `json:"startTime,omitempty"`   // Format: HH:MM
+	EndTime     string `json:"endTime,omitempty"`     // Format: HH:MM
+}
+
 // BookingResponse represents a booking with additional information
 type BookingResponse struct {
-	ID          string    `json:"id"`
-	CourtNumber int       `json:"courtNumber"`
-	BookingDate string    `json:"bookingDate"` // Format: YYYY-MM-DD
-	StartTime   string    `json:"startTime"`   // Format: HH:MM
-	EndTime     string    `json:"endTime"`     // Format: HH:MM
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"createdAt"`
+	ID           string               `json:"id"`
+	VenueID      string               `json:"venueId"`
+	CourtNumber  int                  `json:"courtNumber"`
+	BookingDate  string               `json:"bookingDate"` // Format: YYYY-MM-DD
+	StartTime    string               `json:"startTime"`   // Format: HH:MM
+	EndTime      string               `json:"endTime"`     // Format: HH:MM
+	Status       string               `json:"status"`
+	SeriesID     string               `json:"seriesId,omitempty"`
+	Changes      []BookingChange      `json:"changes,omitempty"`
+	Cancellation *BookingCancellation `json:"cancellation,omitempty"`
+	CreatedAt    time.Time            `json:"createdAt"`
+}
+
+// SeriesRequest represents the data needed to create a weekly recurring booking
+type SeriesRequest struct {
+	VenueID       string `json:"venueId,omitempty"` // ไม่ต้องระบุถ้ามีสนามเดียว
+	CourtNumber   int    `json:"courtNumber" binding:"required"`
+	Weekdays      []int  `json:"weekdays" binding:"required,min=1"` // 0 = อาทิตย์ ... 6 = เสาร์
+	IntervalWeeks int    `json:"intervalWeeks,omitempty"`           // ค่าเริ่มต้นคือทุกสัปดาห์
+	StartDate     string `json:"startDate" binding:"required"`      // Format: YYYY-MM-DD
+	Until         string `json:"until,omitempty"`                   // Format: YYYY-MM-DD, ต้องระบุ Until หรือ Count
+	Count         int    `json:"count,omitempty"`
+	StartTime     string `json:"startTime" binding:"required"` // Format: HH:MM
+	EndTime       string `json:"endTime" binding:"required"`   // Format: HH:MM
+}
+
+// SeriesOccurrenceResult represents the outcome of creating one occurrence of a series
+type SeriesOccurrenceResult struct {
+	BookingDate string `json:"bookingDate"` // Format: YYYY-MM-DD
+	BookingID   string `json:"bookingId,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Rule        string `json:"rule,omitempty"` // กฎการจองที่ครั้งนี้ไม่ผ่าน
+}
+
+// SeriesResponse represents a created series with the occurrences that were booked and those that conflicted
+type SeriesResponse struct {
+	Series    *BookingSeries           `json:"series"`
+	Created   []SeriesOccurrenceResult `json:"created"`
+	Conflicts []SeriesOccurrenceResult `json:"conflicts"`
+}
+
+// SeriesCancelRequest represents which occurrences of a series to cancel
+type SeriesCancelRequest struct {
+	Scope     string `json:"scope" binding:"required"` // occurrence, future, all
+	BookingID string `json:"bookingId,omitempty"`      // ใช้กับ scope = occurrence
+	From      string `json:"from,omitempty"`           // Format: YYYY-MM-DD ใช้กับ scope = future, ค่าเริ่มต้นคือตอนนี้
+	Reason    string `json:"reason,omitempty"`         // ต้องระบุเมื่อยกเลิกการจองของผู้อื่น
+}
+
+// CancelBookingRequest represents the optional body of a cancellation
+type CancelBookingRequest struct {
+	Reason string `json:"reason,omitempty"` // ต้องระบุเมื่อยกเลิกการจองของผู้อื่น
+}
+
+// AdminBookingResponse represents a booking together with the booker's details
+type AdminBookingResponse struct {
+	BookingResponse
+	StudentID string `json:"studentId"`
+	UserName  string `json:"userName"`
+	UserEmail string `json:"userEmail,omitempty"`
+}
+
+// AdminBookingListResponse represents one page of bookings for the admin listing
+type AdminBookingListResponse struct {
+	Bookings   []AdminBookingResponse `json:"bookings"`
+	NextCursor string                 `json:"nextCursor,omitempty"` // ว่างเมื่อไม่มีหน้าถัดไป
+}
+
+// BlackoutRequest represents the data needed to schedule a blackout window
+type BlackoutRequest struct {
+	VenueID      string `json:"venueId,omitempty"` // ไม่ต้องระบุถ้ามีสนามเดียว
+	CourtNumbers []int  `json:"courtNumbers" binding:"required,min=1"`
+	StartDate    string `json:"startDate" binding:"required"` // Format: YYYY-MM-DD
+	StartTime    string `json:"startTime" binding:"required"` // Format: HH:MM
+	EndDate      string `json:"endDate,omitempty"`            // Format: YYYY-MM-DD, ค่าเริ่มต้นคือ StartDate
+	EndTime      string `json:"endTime" binding:"required"`   // Format: HH:MM
+	Reason       string `json:"reason" binding:"required"`
+}
+
+// BlackoutResponse represents a created blackout and the active bookings it overlaps
+type BlackoutResponse struct {
+	Blackout         *Blackout              `json:"blackout"`
+	AffectedBookings []AdminBookingResponse `json:"affectedBookings"`
 }
 
 // AvailabilityRequest represents the data needed to check court availability
 type AvailabilityRequest struct {
+	VenueID     string `json:"venueId,omitempty"`              // ไม่ต้องระบุถ้ามีสนามเดียว
 	CourtNumber int    `json:"courtNumber,omitempty"`          // Optional, all courts if not provided
 	BookingDate string `json:"bookingDate" binding:"required"` // Format: YYYY-MM-DD
 	StartTime   string `json:"startTime" binding:"required"`   // Format: HH:MM
 	EndTime     string `json:"endTime" binding:"required"`     // Format: HH:MM
 }
 
+// TimeWindow represents a start and end time on a booking date
+type TimeWindow struct {
+	StartTime string `json:"startTime"`        // Format: HH:MM
+	EndTime   string `json:"endTime"`          // Format: HH:MM
+	Reason    string `json:"reason,omitempty"` // มีค่าเมื่อเป็นช่วงปิดปรับปรุงคอร์ท
+}
+
 // CourtAvailability represents the availability of a specific court
 type CourtAvailability struct {
-	CourtNumber int  `json:"courtNumber"`
-	IsAvailable bool `json:"isAvailable"`
+	CourtNumber int          `json:"courtNumber"`
+	IsAvailable bool         `json:"isAvailable"`
+	Conflicts   []TimeWindow `json:"conflicts,omitempty"` // ช่วงเวลาที่ถูกจองแล้วและทับกับเวลาที่ขอ
 }
 
 // AvailabilityResponse represents all available courts for a specific time
 type AvailabilityResponse struct {
+	VenueID     string               `json:"venueId"`
 	BookingDate string               `json:"bookingDate"`
 	StartTime   string               `json:"startTime"`
 	EndTime     string               `json:"endTime"`
 	Courts      []*CourtAvailability `json:"courts"` // เปลี่ยนจาก []CourtAvailability เป็น []*CourtAvailability
 }
+
+// ScheduleInterval represents a free or booked part of a court's day
+type ScheduleInterval struct {
+	StartTime string `json:"startTime"` // Format: HH:MM
+	EndTime   string `json:"endTime"`   // Format: HH:MM
+	Status    string `json:"status"`    // free, booked, blocked
+}
+
+// CourtSchedule represents the timetable of a single court
+type CourtSchedule struct {
+	CourtNumber int                `json:"courtNumber"`
+	Name        string             `json:"name"`
+	Intervals   []ScheduleInterval `json:"intervals"`
+}
+
+// ScheduleResponse represents the timetable of all active courts on a date
+type ScheduleResponse struct {
+	VenueID     string          `json:"venueId"`
+	BookingDate string          `json:"bookingDate"`
+	IsClosed    bool            `json:"isClosed"`
+	OpenTime    string          `json:"openTime,omitempty"`  // Format: HH:MM
+	CloseTime   string          `json:"closeTime,omitempty"` // Format: HH:MM
+	SlotMinutes int             `json:"slotMinutes"`
+	Courts      []CourtSchedule `json:"courts"`
+}
+
+// CourtRequest represents the request body for creating or editing a court.
+// VenueID and CourtNumber are only read when creating a court.
+type CourtRequest struct {
+	VenueID     string `json:"venueId"` // ไม่ต้องระบุถ้ามีสนามเดียว
+	CourtNumber int    `json:"courtNumber"`
+	Name        string `json:"name" binding:"required"`
+	Location    string `json:"location"`
+	SurfaceType string `json:"surfaceType"`
+	Capacity    int    `json:"capacity"`
+	IsActive    *bool  `json:"isActive"`
+}
+
+// RoleRequest represents the request body for changing a user's role
+type RoleRequest struct {
+	Role     string   `json:"role" binding:"required"`
+	VenueIDs []string `json:"venueIds"` // สนามที่ดูแล ต้องระบุเมื่อเป็น venue_admin
+}
+
+// VenueResponse represents a venue together with its opening hours
+type VenueResponse struct {
+	*Venue
+	OperatingHours *OperatingHours `json:"operatingHours"`
+}
+
+// VenueRequest represents the request body for creating or editing a venue
+type VenueRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Address  string `json:"address"`
+	Timezone string `json:"timezone"` // IANA timezone เช่น Asia/Bangkok ค่าเริ่มต้นคือ TIMEZONE ใน config
+}
+
+// WebhookRequest represents the request body for registering or updating a webhook
+type WebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Events      []string `json:"events" binding:"required"`
+	Secret      string   `json:"secret"` // ว่างตอนแก้ไข = ใช้ secret เดิม
+	Description string   `json:"description"`
+	IsActive    *bool    `json:"isActive"`
+}
+
+// WebhookEventPayload is the JSON body sent to webhook endpoints
+type WebhookEventPayload struct {
+	ID         string          `json:"id"` // เหมือนกันทุก endpoint สำหรับเหตุการณ์เดียวกัน ใช้กันประมวลผลซ้ำ
+	Event      string          `json:"event"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Booking    *WebhookBooking `json:"booking,omitempty"`
+}
+
+// WebhookBooking is the booking data included in webhook payloads.
+// Times are full timestamps so receivers do not need to know the venue's timezone.
+type WebhookBooking struct {
+	ID           string               `json:"id"`
+	StudentID    string               `json:"studentId"`
+	VenueID      string               `json:"venueId"`
+	CourtNumber  int                  `json:"courtNumber"`
+	StartTime    time.Time            `json:"startTime"`
+	EndTime      time.Time            `json:"endTime"`
+	Status       string               `json:"status"`
+	SeriesID     string               `json:"seriesId,omitempty"`
+	Cancellation *BookingCancellation `json:"cancellation,omitempty"`
+}
@@ -0,0 +1,28 @@
+package notify
+
+import (
+	"fmt"
+
+	"courtopia-reserve/backend/internal/config"
+)
+
+// New creates the notifier selected by cfg.NotifyDriver (smtp, file or memory)
+func New(cfg *config.Config) (Notifier, error) {
+	switch cfg.NotifyDriver {
+	case "smtp":
+		return NewSMTPNotifier(SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			TLSMode:  cfg.SMTPTLSMode,
+		})
+	case "file":
+		return NewFileNotifier(cfg.NotifyFileDir, cfg.SMTPFrom)
+	case "memory":
+		return NewMemoryNotifier(), nil
+	default:
+		return nil, fmt.Errorf("unknown notify driver %q", cfg.NotifyDriver)
+	}
+}
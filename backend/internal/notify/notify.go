@@ -0,0 +1,150 @@
+// Package notify delivers notifications such as booking reminders to users.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message is a notification addressed to one or more recipients
+type Message struct {
+	To      []string
+	Subject string
+	Body    string // plain text
+	HTML    string // optional HTML alternative of Body
+
+	Attachments []Attachment
+}
+
+// Attachment is a file sent along with a message
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Notifier sends messages
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Bytes renders the message as an RFC 5322 email from the given sender.
+// Messages with an HTML part are sent as multipart/alternative, and messages
+// with attachments wrap the body in multipart/mixed.
+func (m Message) Bytes(from string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", newMessageID(from))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	header, body := m.body()
+	if len(m.Attachments) == 0 {
+		writeHeader(&buf, header)
+		buf.Write(body)
+		return buf.Bytes()
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mixed.Boundary())
+	w, _ := mixed.CreatePart(header)
+	w.Write(body)
+	for _, a := range m.Attachments {
+		writeAttachment(mixed, a)
+	}
+	mixed.Close()
+
+	return buf.Bytes()
+}
+
+// body returns the headers and encoded content of the text (and HTML) body
+func (m Message) body() (textproto.MIMEHeader, []byte) {
+	var buf bytes.Buffer
+	header := textproto.MIMEHeader{}
+
+	if m.HTML == "" {
+		header.Set("Content-Type", "text/plain; charset=UTF-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		writeQuotedPrintable(&buf, m.Body)
+		return header, buf.Bytes()
+	}
+
+	mw := multipart.NewWriter(&buf)
+	header.Set("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary()))
+	writePart(mw, "text/plain; charset=UTF-8", m.Body)
+	writePart(mw, "text/html; charset=UTF-8", m.HTML)
+	mw.Close()
+
+	return header, buf.Bytes()
+}
+
+// writeHeader writes MIME headers followed by the blank line that ends them
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if value := header.Get(key); value != "" {
+			fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+// writeAttachment adds a base64 encoded file part to a multipart message
+func writeAttachment(mw *multipart.Writer, a Attachment) {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", fmt.Sprintf("%s; name=%q", a.ContentType, a.Filename))
+	header.Set("Content-Transfer-Encoding", "base64")
+	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.Filename))
+
+	w, _ := mw.CreatePart(header)
+	encoded := base64.StdEncoding.EncodeToString(a.Data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
+
+// writePart adds a quoted-printable body part to a multipart message
+func writePart(mw *multipart.Writer, contentType, body string) {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	w, _ := mw.CreatePart(header)
+	var part bytes.Buffer
+	writeQuotedPrintable(&part, body)
+	w.Write(part.Bytes())
+}
+
+func writeQuotedPrintable(buf *bytes.Buffer, body string) {
+	qp := quotedprintable.NewWriter(buf)
+	qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	qp.Close()
+}
+
+// newMessageID creates a unique Message-ID in the domain of the sender address
+func newMessageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+
+	random := make([]byte, 12)
+	_, _ = rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
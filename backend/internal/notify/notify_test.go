@@ -0,0 +1,296 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"courtopia-reserve/backend/internal/config"
+)
+
+const testFrom = "Courtminton <no-reply@courtminton.local>"
+
+func testMessage() Message {
+	return Message{
+		To:      []string{"65000001@example.com", "65000002@example.com"},
+		Subject: "แจ้งเตือนการจองคอร์ท 1",
+		Body:    "คุณมีการจองคอร์ท 1\nเวลา 18:00-19:00",
+		HTML:    "<p>คุณมีการจองคอร์ท 1</p>",
+	}
+}
+
+// parseMessage reads a rendered message back with the standard library, failing the test if it is malformed
+func parseMessage(t *testing.T, raw []byte) *mail.Message {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("parse message: %v\n%s", err, raw)
+	}
+	return msg
+}
+
+// readParts returns the parts of a multipart body keyed by content type, with quoted-printable decoded
+func readParts(t *testing.T, contentType string, body io.Reader) (map[string]string, []*multipart.Part) {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("parse content type %q: %v", contentType, err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		t.Fatalf("got content type %q, want multipart", mediaType)
+	}
+
+	contents := map[string]string{}
+	var parts []*multipart.Part
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+
+		var data []byte
+		if part.Header.Get("Content-Transfer-Encoding") == "quoted-printable" {
+			data, err = io.ReadAll(quotedprintable.NewReader(part))
+		} else {
+			data, err = io.ReadAll(part)
+		}
+		if err != nil {
+			t.Fatalf("read part body: %v", err)
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		contents[partType] = string(data)
+		parts = append(parts, part)
+	}
+	return contents, parts
+}
+
+func TestMessageBytesHeaders(t *testing.T) {
+	msg := parseMessage(t, testMessage().Bytes(testFrom))
+
+	if got := msg.Header.Get("From"); got != testFrom {
+		t.Errorf("got From %q, want %q", got, testFrom)
+	}
+	if got := msg.Header.Get("To"); got != "65000001@example.com, 65000002@example.com" {
+		t.Errorf("got To %q", got)
+	}
+	if got := msg.Header.Get("MIME-Version"); got != "1.0" {
+		t.Errorf("got MIME-Version %q, want 1.0", got)
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("invalid Date header: %v", err)
+	}
+	if got := msg.Header.Get("Message-ID"); !strings.HasSuffix(got, "@courtminton.local>") {
+		t.Errorf("got Message-ID %q, want one in the sender's domain", got)
+	}
+
+	// หัวเรื่องภาษาไทยต้องถูกเข้ารหัสเป็น encoded-word เพราะ header รับได้แค่ ASCII
+	raw := msg.Header["Subject"][0]
+	if !strings.HasPrefix(raw, "=?UTF-8?b?") {
+		t.Errorf("subject %q is not B-encoded UTF-8", raw)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != testMessage().Subject {
+		t.Errorf("got subject %q, want %q", subject, testMessage().Subject)
+	}
+}
+
+func TestMessageBytesAlternative(t *testing.T) {
+	msg := parseMessage(t, testMessage().Bytes(testFrom))
+
+	contents, parts := readParts(t, msg.Header.Get("Content-Type"), msg.Body)
+	if len(parts) != 2 {
+		t.Fatalf("got %d parts, want 2", len(parts))
+	}
+	// ส่วน text ต้องมาก่อน HTML เพราะ client เลือกส่วนสุดท้ายที่แสดงได้
+	if got := parts[0].Header.Get("Content-Type"); got != "text/plain; charset=UTF-8" {
+		t.Errorf("got first part %q, want text/plain", got)
+	}
+	if got := parts[1].Header.Get("Content-Type"); got != "text/html; charset=UTF-8" {
+		t.Errorf("got second part %q, want text/html", got)
+	}
+	if got := contents["text/plain"]; got != "คุณมีการจองคอร์ท 1\r\nเวลา 18:00-19:00" {
+		t.Errorf("got text body %q", got)
+	}
+	if got := contents["text/html"]; got != testMessage().HTML {
+		t.Errorf("got HTML body %q", got)
+	}
+}
+
+func TestMessageBytesPlainText(t *testing.T) {
+	m := testMessage()
+	m.HTML = ""
+	msg := parseMessage(t, m.Bytes(testFrom))
+
+	if got := msg.Header.Get("Content-Type"); got != "text/plain; charset=UTF-8" {
+		t.Errorf("got Content-Type %q, want text/plain", got)
+	}
+	if got := msg.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+		t.Errorf("got Content-Transfer-Encoding %q, want quoted-printable", got)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "คุณมีการจองคอร์ท 1\r\nเวลา 18:00-19:00" {
+		t.Errorf("got body %q", body)
+	}
+}
+
+func TestMessageBytesAttachment(t *testing.T) {
+	m := testMessage()
+	ics := []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")
+	m.Attachments = []Attachment{{Filename: "booking.ics", ContentType: "text/calendar", Data: ics}}
+	msg := parseMessage(t, m.Bytes(testFrom))
+
+	_, parts := readParts(t, msg.Header.Get("Content-Type"), msg.Body)
+	if len(parts) != 2 {
+		t.Fatalf("got %d parts, want the body and one attachment", len(parts))
+	}
+	if got := parts[0].Header.Get("Content-Type"); !strings.HasPrefix(got, "multipart/alternative") {
+		t.Errorf("got body part %q, want multipart/alternative", got)
+	}
+	if got := parts[1].Header.Get("Content-Disposition"); got != `attachment; filename="booking.ics"` {
+		t.Errorf("got Content-Disposition %q", got)
+	}
+
+	// เนื้อหาไฟล์แนบต้องถอด base64 กลับมาได้ตรงกับต้นฉบับ
+	raw := m.Bytes(testFrom)
+	encoded := base64.StdEncoding.EncodeToString(ics)
+	if !bytes.Contains(raw, []byte(encoded)) {
+		t.Errorf("attachment data %q not found in message", encoded)
+	}
+}
+
+func TestMemoryNotifier(t *testing.T) {
+	n := NewMemoryNotifier()
+	ctx := context.Background()
+
+	for _, subject := range []string{"first", "second"} {
+		if err := n.Send(ctx, Message{To: []string{"65000001@example.com"}, Subject: subject}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	messages := n.Messages()
+	if len(messages) != 2 || messages[0].Subject != "first" || messages[1].Subject != "second" {
+		t.Fatalf("got %+v, want the two messages in order", messages)
+	}
+
+	// Messages คืนสำเนา แก้ slice ที่ได้ไปต้องไม่กระทบข้อความที่บันทึกไว้
+	messages[0].Subject = "changed"
+	if n.Messages()[0].Subject != "first" {
+		t.Error("changing the returned slice changed the recorded messages")
+	}
+}
+
+func TestFileNotifier(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	n, err := NewFileNotifier(dir, testFrom)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for range 2 {
+		if err := n.Send(ctx, testMessage()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2", len(files))
+	}
+
+	raw, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := parseMessage(t, raw)
+	if got := msg.Header.Get("From"); got != testFrom {
+		t.Errorf("got From %q, want %q", got, testFrom)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "memory",
+			cfg:  config.Config{NotifyDriver: "memory"},
+			want: "*notify.MemoryNotifier",
+		},
+		{
+			name: "file",
+			cfg:  config.Config{NotifyDriver: "file", NotifyFileDir: t.TempDir(), SMTPFrom: testFrom},
+			want: "*notify.FileNotifier",
+		},
+		{
+			name: "smtp",
+			cfg:  config.Config{NotifyDriver: "smtp", SMTPHost: "smtp.example.com", SMTPPort: 587, SMTPFrom: testFrom, SMTPTLSMode: TLSStartTLS},
+			want: "*notify.SMTPNotifier",
+		},
+		{
+			name:    "smtp without host",
+			cfg:     config.Config{NotifyDriver: "smtp", SMTPPort: 587, SMTPFrom: testFrom, SMTPTLSMode: TLSStartTLS},
+			wantErr: true,
+		},
+		{
+			name:    "smtp with unknown tls mode",
+			cfg:     config.Config{NotifyDriver: "smtp", SMTPHost: "smtp.example.com", SMTPPort: 587, SMTPFrom: testFrom, SMTPTLSMode: "ssl"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown driver",
+			cfg:     config.Config{NotifyDriver: "pigeon"},
+			wantErr: true,
+		},
+		{
+			name:    "no driver",
+			cfg:     config.Config{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := New(&tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %T, want an error", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprintf("%T", n); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
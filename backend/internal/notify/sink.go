@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MemoryNotifier keeps sent messages in memory, for development and tests
+type MemoryNotifier struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemoryNotifier creates a new in-memory notifier
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+// Send records the message
+func (n *MemoryNotifier) Send(_ context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of the messages sent so far
+func (n *MemoryNotifier) Messages() []Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Message(nil), n.messages...)
+}
+
+// FileNotifier writes each message as an .eml file into a directory, for development
+type FileNotifier struct {
+	dir  string
+	from string
+	mu   sync.Mutex
+	seq  int
+}
+
+// NewFileNotifier creates a notifier that writes messages into dir, creating it if needed
+func NewFileNotifier(dir, from string) (*FileNotifier, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileNotifier{dir: dir, from: from}, nil
+}
+
+// Send writes the message to a new file
+func (n *FileNotifier) Send(_ context.Context, msg Message) error {
+	n.mu.Lock()
+	n.seq++
+	name := fmt.Sprintf("%s-%04d.eml", time.Now().Format("20060102-150405"), n.seq)
+	n.mu.Unlock()
+
+	return os.WriteFile(filepath.Join(n.dir, name), msg.Bytes(n.from), 0o644)
+}
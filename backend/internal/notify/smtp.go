@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// TLS modes supported by the SMTP notifier
+const (
+	TLSNone     = "none"     // plain connection, e.g. a local SMTP stand-in
+	TLSStartTLS = "starttls" // upgrade with STARTTLS, usually port 587
+	TLSImplicit = "tls"      // TLS from the first byte, usually port 465
+)
+
+// SMTPConfig holds the settings of an SMTP server
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	TLSMode  string
+}
+
+// SMTPNotifier sends messages through an SMTP server
+type SMTPNotifier struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPNotifier creates a new SMTP notifier
+func NewSMTPNotifier(cfg SMTPConfig) (*SMTPNotifier, error) {
+	if cfg.Host == "" || cfg.Port == 0 || cfg.From == "" {
+		return nil, errors.New("smtp host, port and from address are required")
+	}
+	switch cfg.TLSMode {
+	case TLSNone, TLSStartTLS, TLSImplicit:
+	default:
+		return nil, fmt.Errorf("unknown smtp tls mode %q", cfg.TLSMode)
+	}
+	return &SMTPNotifier{cfg: cfg}, nil
+}
+
+// Send delivers the message to all of its recipients
+func (n *SMTPNotifier) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: n.cfg.Host}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	if n.cfg.TLSMode == TLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("dial smtp: %w", err)
+	}
+
+	// ไม่ให้การส่งค้างนานเกินไปถ้า server ไม่ตอบ
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake: %w", err)
+	}
+	defer client.Close()
+
+	if n.cfg.TLSMode == TLSStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+
+	if n.cfg.Username != "" {
+		auth := smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.cfg.From); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp rcpt %s: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(msg.Bytes(n.cfg.From)); err != nil {
+		return fmt.Errorf("smtp write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data close: %w", err)
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template names
+const (
+	TemplateBookingConfirmation = "booking_confirmation"
+	TemplateBookingCancellation = "booking_cancellation"
+	TemplateBookingChange       = "booking_change"
+	TemplateReminder            = "reminder"
+	TemplateWaitlistPromotion   = "waitlist_promotion"
+	TemplateVerifyEmail         = "verify_email"
+	TemplatePasswordReset       = "password_reset"
+)
+
+// Supported languages
+const (
+	LanguageEnglish = "en"
+	LanguageThai    = "th"
+
+	// DefaultLanguage is used when a user has not chosen a language
+	DefaultLanguage = LanguageEnglish
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// templateNames lists every template that must exist in each language
+var templateNames = []string{
+	TemplateBookingConfirmation,
+	TemplateBookingCancellation,
+	TemplateBookingChange,
+	TemplateReminder,
+	TemplateWaitlistPromotion,
+	TemplateVerifyEmail,
+	TemplatePasswordReset,
+}
+
+type emailTemplate struct {
+	text *texttemplate.Template // defines "subject" and "text"
+	html *htmltemplate.Template // renders "layout"
+}
+
+// templates are parsed once at startup, keyed by language then template name
+var templates = loadTemplates()
+
+func loadTemplates() map[string]map[string]*emailTemplate {
+	all := make(map[string]map[string]*emailTemplate)
+	for _, lang := range []string{LanguageEnglish, LanguageThai} {
+		all[lang] = make(map[string]*emailTemplate)
+		for _, name := range templateNames {
+			all[lang][name] = &emailTemplate{
+				text: texttemplate.Must(texttemplate.ParseFS(templateFS,
+					fmt.Sprintf("templates/%s/%s.txt", lang, name))),
+				html: htmltemplate.Must(htmltemplate.ParseFS(templateFS,
+					"templates/layout.html",
+					fmt.Sprintf("templates/%s/labels.html", lang),
+					fmt.Sprintf("templates/%s/%s.html", lang, name))),
+			}
+		}
+	}
+	return all
+}
+
+// Data is the data available to email templates.
+// Booking emails fill in the court and time; account emails fill in the link instead.
+type Data struct {
+	Lang        string
+	Name        string
+	Venue       string // ชื่อสนาม
+	CourtNumber int
+	Date        string // Format: YYYY-MM-DD
+	StartTime   string // Format: HH:MM
+	EndTime     string // Format: HH:MM
+
+	// คอร์ทและเวลาเดิมของการจองที่ถูกย้าย
+	PreviousCourtNumber int
+	PreviousDate        string // Format: YYYY-MM-DD
+	PreviousStartTime   string // Format: HH:MM
+	PreviousEndTime     string // Format: HH:MM
+
+	Reason string // เหตุผลที่ยกเลิกการจอง
+
+	Link         string // ลิงก์ยืนยันอีเมลหรือตั้งรหัสผ่านใหม่
+	ExpiresHours int    // ลิงก์ใช้ได้กี่ชั่วโมง
+}
+
+// SupportedLanguage reports whether emails can be rendered in lang
+func SupportedLanguage(lang string) bool {
+	_, ok := templates[lang]
+	return ok
+}
+
+// Render builds a message from the named template in the given language,
+// falling back to DefaultLanguage for unknown languages
+func Render(lang, name string, to string, data Data) (Message, error) {
+	if !SupportedLanguage(lang) {
+		lang = DefaultLanguage
+	}
+	data.Lang = lang
+
+	tmpl, ok := templates[lang][name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:      []string{to},
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
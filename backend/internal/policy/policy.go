@@ -0,0 +1,214 @@
+// Package policy evaluates the fair-use rules that limit how much a user can book.
+package policy
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// Rule names reported in a Violation
+const (
+	RuleMaxActiveBookings = "max_active_bookings"
+	RuleMaxHoursPerDay    = "max_hours_per_day"
+	RuleMaxHoursPerWeek   = "max_hours_per_week"
+	RuleMaxDaysAhead      = "max_days_ahead"
+	RuleNoBackToBack      = "no_back_to_back"
+	RuleOverlapping       = "overlapping_booking"
+	RuleNoShowBan         = "no_show_ban"
+	RuleUnverifiedEmail   = "unverified_email"
+)
+
+// Violation describes the rule a booking request failed
+type Violation struct {
+	Rule    string `json:"rule"`
+	Limit   int    `json:"limit,omitempty"`
+	Message string `json:"error"`
+}
+
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// Request is the booking being evaluated. StartTime and EndTime are in the venue's
+// timezone, which decides where a day and a week begin.
+type Request struct {
+	VenueID     primitive.ObjectID
+	CourtNumber int
+	StartTime   time.Time
+	EndTime     time.Time
+	Now         time.Time
+}
+
+// Usage is what the user has already booked
+type Usage struct {
+	// ActiveBookings is the number of active bookings that have not ended yet
+	ActiveBookings int
+	// Bookings are the user's active and completed bookings in the week of the request,
+	// including those that start just before the week and may still overlap it
+	Bookings []*models.Booking
+	// BannedUntil is set when the user is banned from booking after repeated no-shows
+	BannedUntil *time.Time
+	// EmailVerified is false until the user confirms their email address
+	EmailVerified bool
+}
+
+// rule checks one limit of the policy
+type rule func(p *models.BookingPolicy, req Request, usage Usage) *Violation
+
+// rules are evaluated in order and the first violation is returned
+var rules = []rule{
+	checkNoShowBan,
+	checkUnverifiedEmail,
+	checkDaysAhead,
+	checkOverlapping,
+	checkActiveBookings,
+	checkHoursPerDay,
+	checkHoursPerWeek,
+	checkBackToBack,
+}
+
+// Evaluate checks a booking request against the policy and returns the first rule it breaks, or nil
+func Evaluate(p *models.BookingPolicy, req Request, usage Usage) *Violation {
+	for _, r := range rules {
+		if v := r(p, req, usage); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// WeekRange returns the start of the Monday-based week containing t and the start of the next week
+func WeekRange(t time.Time) (time.Time, time.Time) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := (int(day.Weekday()) + 6) % 7 // จันทร์ = 0
+	start := day.AddDate(0, 0, -offset)
+	return start, start.AddDate(0, 0, 7)
+}
+
+func checkNoShowBan(_ *models.BookingPolicy, req Request, usage Usage) *Violation {
+	if usage.BannedUntil == nil || !usage.BannedUntil.After(req.Now) {
+		return nil
+	}
+	return &Violation{
+		Rule:    RuleNoShowBan,
+		Message: fmt.Sprintf("You cannot book until %s because of repeated no-shows", usage.BannedUntil.In(req.StartTime.Location()).Format("2006-01-02 15:04")),
+	}
+}
+
+func checkUnverifiedEmail(p *models.BookingPolicy, _ Request, usage Usage) *Violation {
+	if usage.EmailVerified || p.UnverifiedMaxActiveBookings <= 0 {
+		return nil
+	}
+	if usage.ActiveBookings >= p.UnverifiedMaxActiveBookings {
+		return &Violation{
+			Rule:    RuleUnverifiedEmail,
+			Limit:   p.UnverifiedMaxActiveBookings,
+			Message: fmt.Sprintf("Verify your email address to have more than %d upcoming bookings", p.UnverifiedMaxActiveBookings),
+		}
+	}
+	return nil
+}
+
+func checkDaysAhead(p *models.BookingPolicy, req Request, _ Usage) *Violation {
+	if p.MaxDaysAhead <= 0 {
+		return nil
+	}
+	if req.StartTime.After(req.Now.AddDate(0, 0, p.MaxDaysAhead)) {
+		return &Violation{
+			Rule:    RuleMaxDaysAhead,
+			Limit:   p.MaxDaysAhead,
+			Message: fmt.Sprintf("Bookings can be made at most %d days in advance", p.MaxDaysAhead),
+		}
+	}
+	return nil
+}
+
+func checkOverlapping(_ *models.BookingPolicy, req Request, usage Usage) *Violation {
+	for _, b := range usage.Bookings {
+		if b.Status == "active" && b.StartTime.Before(req.EndTime) && b.EndTime.After(req.StartTime) {
+			return &Violation{
+				Rule:    RuleOverlapping,
+				Message: "You already have a booking at this time",
+			}
+		}
+	}
+	return nil
+}
+
+func checkActiveBookings(p *models.BookingPolicy, _ Request, usage Usage) *Violation {
+	if p.MaxActiveBookings <= 0 {
+		return nil
+	}
+	if usage.ActiveBookings >= p.MaxActiveBookings {
+		return &Violation{
+			Rule:    RuleMaxActiveBookings,
+			Limit:   p.MaxActiveBookings,
+			Message: fmt.Sprintf("You can have at most %d upcoming bookings", p.MaxActiveBookings),
+		}
+	}
+	return nil
+}
+
+func checkHoursPerDay(p *models.BookingPolicy, req Request, usage Usage) *Violation {
+	if p.MaxHoursPerDay <= 0 {
+		return nil
+	}
+	y, m, d := req.StartTime.Date()
+	booked := req.EndTime.Sub(req.StartTime)
+	for _, b := range usage.Bookings {
+		if by, bm, bd := b.StartTime.In(req.StartTime.Location()).Date(); by == y && bm == m && bd == d {
+			booked += b.EndTime.Sub(b.StartTime)
+		}
+	}
+	if booked > time.Duration(p.MaxHoursPerDay)*time.Hour {
+		return &Violation{
+			Rule:    RuleMaxHoursPerDay,
+			Limit:   p.MaxHoursPerDay,
+			Message: fmt.Sprintf("You can book at most %d hours per day", p.MaxHoursPerDay),
+		}
+	}
+	return nil
+}
+
+func checkHoursPerWeek(p *models.BookingPolicy, req Request, usage Usage) *Violation {
+	if p.MaxHoursPerWeek <= 0 {
+		return nil
+	}
+	weekStart, weekEnd := WeekRange(req.StartTime)
+	booked := req.EndTime.Sub(req.StartTime)
+	for _, b := range usage.Bookings {
+		if !b.StartTime.Before(weekStart) && b.StartTime.Before(weekEnd) {
+			booked += b.EndTime.Sub(b.StartTime)
+		}
+	}
+	if booked > time.Duration(p.MaxHoursPerWeek)*time.Hour {
+		return &Violation{
+			Rule:    RuleMaxHoursPerWeek,
+			Limit:   p.MaxHoursPerWeek,
+			Message: fmt.Sprintf("You can book at most %d hours per week", p.MaxHoursPerWeek),
+		}
+	}
+	return nil
+}
+
+func checkBackToBack(p *models.BookingPolicy, req Request, usage Usage) *Violation {
+	if !p.NoBackToBack {
+		return nil
+	}
+	for _, b := range usage.Bookings {
+		if b.VenueID != req.VenueID || b.CourtNumber != req.CourtNumber {
+			continue
+		}
+		if b.EndTime.Equal(req.StartTime) || b.StartTime.Equal(req.EndTime) {
+			return &Violation{
+				Rule:    RuleNoBackToBack,
+				Message: "Back-to-back bookings on the same court are not allowed",
+			}
+		}
+	}
+	return nil
+}
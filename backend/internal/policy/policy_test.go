@@ -0,0 +1,329 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+var (
+	bangkok = mustLoadLocation("Asia/Bangkok")
+	venueID = primitive.NewObjectID()
+)
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// at returns a time on a day of January 2030 in Bangkok. 2030-01-07 is a Monday.
+func at(day, hour, minute int) time.Time {
+	return time.Date(2030, 1, day, hour, minute, 0, 0, bangkok)
+}
+
+// testPolicy returns a policy with every rule switched off, so each test enables only the rule it covers
+func testPolicy(configure func(p *models.BookingPolicy)) *models.BookingPolicy {
+	p := &models.BookingPolicy{}
+	if configure != nil {
+		configure(p)
+	}
+	return p
+}
+
+// request is a booking on court 1 from start for the given duration, made on Monday 2030-01-07 at 09:00
+func request(start time.Time, duration time.Duration) Request {
+	return Request{
+		VenueID:     venueID,
+		CourtNumber: 1,
+		StartTime:   start,
+		EndTime:     start.Add(duration),
+		Now:         at(7, 9, 0),
+	}
+}
+
+// booking is an existing active booking on the given court
+func booking(courtNumber int, start time.Time, duration time.Duration) *models.Booking {
+	return &models.Booking{
+		ID:          primitive.NewObjectID(),
+		VenueID:     venueID,
+		CourtNumber: courtNumber,
+		StartTime:   start,
+		EndTime:     start.Add(duration),
+		Status:      "active",
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	verified := Usage{EmailVerified: true}
+	bannedUntil := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		name     string
+		policy   *models.BookingPolicy
+		request  Request
+		usage    Usage
+		wantRule string
+	}{
+		{
+			name:    "no limits",
+			policy:  testPolicy(nil),
+			request: request(at(8, 18, 0), time.Hour),
+			usage:   verified,
+		},
+
+		// ห้ามจองหลังไม่มาใช้คอร์ท
+		{
+			name:     "banned",
+			policy:   testPolicy(nil),
+			request:  request(at(8, 18, 0), time.Hour),
+			usage:    Usage{EmailVerified: true, BannedUntil: bannedUntil(at(7, 9, 1))},
+			wantRule: RuleNoShowBan,
+		},
+		{
+			name:    "ban ends now",
+			policy:  testPolicy(nil),
+			request: request(at(8, 18, 0), time.Hour),
+			usage:   Usage{EmailVerified: true, BannedUntil: bannedUntil(at(7, 9, 0))},
+		},
+		{
+			name:    "ban ended",
+			policy:  testPolicy(nil),
+			request: request(at(8, 18, 0), time.Hour),
+			usage:   Usage{EmailVerified: true, BannedUntil: bannedUntil(at(6, 9, 0))},
+		},
+
+		// ผู้ใช้ที่ยังไม่ยืนยันอีเมล
+		{
+			name:    "unverified below cap",
+			policy:  testPolicy(func(p *models.BookingPolicy) { p.UnverifiedMaxActiveBookings = 2 }),
+			request: request(at(8, 18, 0), time.Hour),
+			usage:   Usage{ActiveBookings: 1},
+		},
+		{
+			name:     "unverified at cap",
+			policy:   testPolicy(func(p *models.BookingPolicy) { p.UnverifiedMaxActiveBookings = 2 }),
+			request:  request(at(8, 18, 0), time.Hour),
+			usage:    Usage{ActiveBookings: 2},
+			wantRule: RuleUnverifiedEmail,
+		},
+		{
+			name:    "verified above unverified cap",
+			policy:  testPolicy(func(p *models.BookingPolicy) { p.UnverifiedMaxActiveBookings = 2 }),
+			request: request(at(8, 18, 0), time.Hour),
+			usage:   Usage{ActiveBookings: 2, EmailVerified: true},
+		},
+		{
+			name:    "unverified cap disabled",
+			policy:  testPolicy(nil),
+			request: request(at(8, 18, 0), time.Hour),
+			usage:   Usage{ActiveBookings: 5},
+		},
+
+		// จองล่วงหน้าได้ไม่เกินจำนวนวัน
+		{
+			name:    "exactly max days ahead",
+			policy:  testPolicy(func(p *models.BookingPolicy) { p.MaxDaysAhead = 14 }),
+			request: request(at(21, 9, 0), time.Hour),
+			usage:   verified,
+		},
+		{
+			name:     "beyond max days ahead",
+			policy:   testPolicy(func(p *models.BookingPolicy) { p.MaxDaysAhead = 14 }),
+			request:  request(at(21, 9, 30), time.Hour),
+			usage:    verified,
+			wantRule: RuleMaxDaysAhead,
+		},
+
+		// การจองที่ทับเวลากัน
+		{
+			name:     "overlaps a booking on another court",
+			policy:   testPolicy(nil),
+			request:  request(at(8, 18, 0), time.Hour),
+			usage:    Usage{EmailVerified: true, Bookings: []*models.Booking{booking(2, at(8, 18, 30), time.Hour)}},
+			wantRule: RuleOverlapping,
+		},
+		{
+			name:    "ends when another booking starts",
+			policy:  testPolicy(nil),
+			request: request(at(8, 18, 0), time.Hour),
+			usage:   Usage{EmailVerified: true, Bookings: []*models.Booking{booking(2, at(8, 19, 0), time.Hour)}},
+		},
+
+		// จำนวนการจองที่ยังไม่ถึงเวลา
+		{
+			name:    "below active cap",
+			policy:  testPolicy(func(p *models.BookingPolicy) { p.MaxActiveBookings = 3 }),
+			request: request(at(8, 18, 0), time.Hour),
+			usage:   Usage{EmailVerified: true, ActiveBookings: 2},
+		},
+		{
+			name:     "at active cap",
+			policy:   testPolicy(func(p *models.BookingPolicy) { p.MaxActiveBookings = 3 }),
+			request:  request(at(8, 18, 0), time.Hour),
+			usage:    Usage{EmailVerified: true, ActiveBookings: 3},
+			wantRule: RuleMaxActiveBookings,
+		},
+
+		// ชั่วโมงต่อวัน
+		{
+			name:    "exactly max hours per day",
+			policy:  testPolicy(func(p *models.BookingPolicy) { p.MaxHoursPerDay = 2 }),
+			request: request(at(8, 18, 0), time.Hour),
+			usage:   Usage{EmailVerified: true, Bookings: []*models.Booking{booking(2, at(8, 10, 0), time.Hour)}},
+		},
+		{
+			name:     "over max hours per day",
+			policy:   testPolicy(func(p *models.BookingPolicy) { p.MaxHoursPerDay = 2 }),
+			request:  request(at(8, 18, 0), time.Hour),
+			usage:    Usage{EmailVerified: true, Bookings: []*models.Booking{booking(2, at(8, 10, 0), 90*time.Minute)}},
+			wantRule: RuleMaxHoursPerDay,
+		},
+		{
+			// 06:00 วันอังคารที่กรุงเทพฯ ตรงกับ 23:00 UTC วันจันทร์ ต้องนับเป็นวันอังคาร
+			name:     "day is taken from the venue timezone",
+			policy:   testPolicy(func(p *models.BookingPolicy) { p.MaxHoursPerDay = 2 }),
+			request:  request(at(8, 18, 0), time.Hour),
+			usage:    Usage{EmailVerified: true, Bookings: []*models.Booking{booking(2, at(8, 6, 0).UTC(), 90*time.Minute)}},
+			wantRule: RuleMaxHoursPerDay,
+		},
+		{
+			name:    "other days are not counted",
+			policy:  testPolicy(func(p *models.BookingPolicy) { p.MaxHoursPerDay = 2 }),
+			request: request(at(8, 18, 0), time.Hour),
+			usage:   Usage{EmailVerified: true, Bookings: []*models.Booking{booking(2, at(7, 18, 0), 2*time.Hour)}},
+		},
+
+		// ชั่วโมงต่อสัปดาห์
+		{
+			name:    "exactly max hours per week",
+			policy:  testPolicy(func(p *models.BookingPolicy) { p.MaxHoursPerWeek = 3 }),
+			request: request(at(13, 18, 0), time.Hour),
+			usage:   Usage{EmailVerified: true, Bookings: []*models.Booking{booking(2, at(7, 10, 0), 2*time.Hour)}},
+		},
+		{
+			name:     "over max hours per week",
+			policy:   testPolicy(func(p *models.BookingPolicy) { p.MaxHoursPerWeek = 3 }),
+			request:  request(at(13, 18, 0), time.Hour),
+			usage:    Usage{EmailVerified: true, Bookings: []*models.Booking{booking(2, at(7, 10, 0), 150*time.Minute)}},
+			wantRule: RuleMaxHoursPerWeek,
+		},
+		{
+			// 06:00 วันจันทร์ที่กรุงเทพฯ ตรงกับ 23:00 UTC วันอาทิตย์ของสัปดาห์ก่อน ต้องนับในสัปดาห์นี้
+			name:     "week is taken from the venue timezone",
+			policy:   testPolicy(func(p *models.BookingPolicy) { p.MaxHoursPerWeek = 3 }),
+			request:  request(at(9, 18, 0), time.Hour),
+			usage:    Usage{EmailVerified: true, Bookings: []*models.Booking{booking(2, at(7, 6, 0).UTC(), 150*time.Minute)}},
+			wantRule: RuleMaxHoursPerWeek,
+		},
+		{
+			name:    "previous week is not counted",
+			policy:  testPolicy(func(p *models.BookingPolicy) { p.MaxHoursPerWeek = 3 }),
+			request: request(at(9, 18, 0), time.Hour),
+			usage:   Usage{EmailVerified: true, Bookings: []*models.Booking{booking(2, at(6, 21, 0), 3*time.Hour)}},
+		},
+
+		// การจองต่อเนื่องบนคอร์ทเดิม
+		{
+			name:     "right after a booking on the same court",
+			policy:   testPolicy(func(p *models.BookingPolicy) { p.NoBackToBack = true }),
+			request:  request(at(8, 18, 0), time.Hour),
+			usage:    Usage{EmailVerified: true, Bookings: []*models.Booking{booking(1, at(8, 17, 0), time.Hour)}},
+			wantRule: RuleNoBackToBack,
+		},
+		{
+			name:     "right before a booking on the same court",
+			policy:   testPolicy(func(p *models.BookingPolicy) { p.NoBackToBack = true }),
+			request:  request(at(8, 18, 0), time.Hour),
+			usage:    Usage{EmailVerified: true, Bookings: []*models.Booking{booking(1, at(8, 19, 0), time.Hour)}},
+			wantRule: RuleNoBackToBack,
+		},
+		{
+			name:    "right after a booking on another court",
+			policy:  testPolicy(func(p *models.BookingPolicy) { p.NoBackToBack = true }),
+			request: request(at(8, 18, 0), time.Hour),
+			usage:   Usage{EmailVerified: true, Bookings: []*models.Booking{booking(2, at(8, 17, 0), time.Hour)}},
+		},
+		{
+			name:    "gap before the booking",
+			policy:  testPolicy(func(p *models.BookingPolicy) { p.NoBackToBack = true }),
+			request: request(at(8, 18, 0), time.Hour),
+			usage:   Usage{EmailVerified: true, Bookings: []*models.Booking{booking(1, at(8, 16, 30), time.Hour)}},
+		},
+		{
+			name:    "back-to-back allowed",
+			policy:  testPolicy(nil),
+			request: request(at(8, 18, 0), time.Hour),
+			usage:   Usage{EmailVerified: true, Bookings: []*models.Booking{booking(1, at(8, 17, 0), time.Hour)}},
+		},
+
+		// กฎถูกตรวจตามลำดับและคืนข้อแรกที่ไม่ผ่าน
+		{
+			name: "first broken rule wins",
+			policy: testPolicy(func(p *models.BookingPolicy) {
+				p.MaxActiveBookings = 1
+				p.MaxDaysAhead = 1
+			}),
+			request:  request(at(20, 18, 0), time.Hour),
+			usage:    Usage{EmailVerified: true, ActiveBookings: 1, BannedUntil: bannedUntil(at(8, 0, 0))},
+			wantRule: RuleNoShowBan,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Evaluate(tt.policy, tt.request, tt.usage)
+			if tt.wantRule == "" {
+				if v != nil {
+					t.Fatalf("got violation %s (%s), want none", v.Rule, v.Message)
+				}
+				return
+			}
+			if v == nil {
+				t.Fatalf("got no violation, want %s", tt.wantRule)
+			}
+			if v.Rule != tt.wantRule {
+				t.Fatalf("got violation %s (%s), want %s", v.Rule, v.Message, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestWeekRange(t *testing.T) {
+	utc := time.UTC
+
+	tests := []struct {
+		name      string
+		t         time.Time
+		wantStart time.Time
+	}{
+		{name: "monday midnight", t: at(7, 0, 0), wantStart: at(7, 0, 0)},
+		{name: "midweek", t: at(9, 18, 0), wantStart: at(7, 0, 0)},
+		{name: "sunday night", t: at(13, 23, 59), wantStart: at(7, 0, 0)},
+		{name: "next monday", t: at(14, 0, 0), wantStart: at(14, 0, 0)},
+		// เวลาเดียวกันแต่ตาม UTC ยังเป็นวันอาทิตย์ของสัปดาห์ก่อน
+		{name: "utc sunday", t: at(7, 6, 0).In(utc), wantStart: time.Date(2029, 12, 31, 0, 0, 0, 0, utc)},
+		{name: "bangkok monday", t: at(7, 6, 0), wantStart: at(7, 0, 0)},
+		{name: "across a month boundary", t: time.Date(2030, 2, 2, 12, 0, 0, 0, bangkok), wantStart: time.Date(2030, 1, 28, 0, 0, 0, 0, bangkok)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := WeekRange(tt.t)
+			if !start.Equal(tt.wantStart) || start.Location() != tt.t.Location() {
+				t.Errorf("start is %s, want %s", start, tt.wantStart)
+			}
+			if want := tt.wantStart.AddDate(0, 0, 7); !end.Equal(want) {
+				t.Errorf("end is %s, want %s", end, want)
+			}
+			if start.Weekday() != time.Monday {
+				t.Errorf("start is a %s, want Monday", start.Weekday())
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Package rbac maps user roles to the permissions they grant
+package rbac
+
+import "slices"
+
+// Roles
+const (
+	RoleStudent     = "student"
+	RoleStaff       = "staff"
+	RoleClubManager = "club_manager"
+	RoleVenueAdmin  = "venue_admin" // ผู้ดูแลสนาม จัดการได้เฉพาะสนามที่ได้รับมอบหมาย
+	RoleAdmin       = "admin"
+)
+
+// Permission names one thing a user may do
+type Permission string
+
+// Permissions
+const (
+	BookingsReadAll      Permission = "bookings:read_all"      // ดูการจองของทุกคน
+	BookingsCancelAny    Permission = "bookings:cancel_any"    // ยกเลิกการจองของคนอื่น
+	BookingsModifyAny    Permission = "bookings:modify_any"    // ย้ายคอร์ทหรือเวลาของการจองของคนอื่น
+	BookingsCheckInAny   Permission = "bookings:check_in_any"  // เช็กอินให้ผู้อื่นโดยไม่ต้องใช้รหัสคอร์ท
+	BookingsBypassPolicy Permission = "bookings:bypass_policy" // จองได้โดยไม่ถูกจำกัดโควตา
+	SeriesCreate         Permission = "series:create"          // สร้างการจองแบบประจำ
+	CourtsOperate        Permission = "courts:operate"         // เปิด/ปิดคอร์ท และดูรหัสเช็กอิน
+	CourtsManage         Permission = "courts:manage"          // ตั้งค่าคอร์ท
+	VenuesManage         Permission = "venues:manage"          // แก้ไขข้อมูลสนาม เวลาเปิดปิด และช่วงปิดปรับปรุง
+	VenuesCreate         Permission = "venues:create"          // เพิ่มสนามใหม่
+	SettingsManage       Permission = "settings:manage"        // กติกาการจอง
+	NotificationsManage  Permission = "notifications:manage"   // ดูและส่งอีเมลแจ้งเตือนใหม่
+	WebhooksManage       Permission = "webhooks:manage"
+	UsersManage          Permission = "users:manage" // กำหนด role และปลดล็อกการ login
+)
+
+// roles lists the permissions of every role. Admins get every permission.
+var roles = map[string][]Permission{
+	RoleStudent: {},
+	RoleClubManager: {
+		SeriesCreate,
+	},
+	RoleStaff: {
+		BookingsReadAll,
+		BookingsCancelAny,
+		BookingsModifyAny,
+		BookingsCheckInAny,
+		CourtsOperate,
+	},
+	RoleVenueAdmin: {
+		BookingsReadAll,
+		BookingsCancelAny,
+		BookingsModifyAny,
+		BookingsCheckInAny,
+		CourtsOperate,
+		CourtsManage,
+		VenuesManage,
+	},
+	RoleAdmin: {
+		BookingsReadAll,
+		BookingsCancelAny,
+		BookingsModifyAny,
+		BookingsCheckInAny,
+		BookingsBypassPolicy,
+		SeriesCreate,
+		CourtsOperate,
+		CourtsManage,
+		VenuesManage,
+		VenuesCreate,
+		SettingsManage,
+		NotificationsManage,
+		WebhooksManage,
+		UsersManage,
+	},
+}
+
+// legacyRoles maps the role names used before permissions were introduced
+var legacyRoles = map[string]string{
+	"user": RoleStudent,
+	"club": RoleClubManager,
+}
+
+// Roles returns every role in order of increasing privilege
+func Roles() []string {
+	return []string{RoleStudent, RoleClubManager, RoleStaff, RoleVenueAdmin, RoleAdmin}
+}
+
+// VenueScoped reports whether a role only applies to the venues assigned to the user.
+// Other roles apply to every venue unless the user is assigned to specific venues.
+func VenueScoped(role string) bool {
+	return Normalize(role) == RoleVenueAdmin
+}
+
+// LegacyRoles returns the old role names and the roles that replace them
+func LegacyRoles() map[string]string {
+	return legacyRoles
+}
+
+// Normalize returns the current name of a role, translating legacy names
+func Normalize(role string) string {
+	if current, ok := legacyRoles[role]; ok {
+		return current
+	}
+	return role
+}
+
+// ValidRole reports whether role is one of the current roles
+func ValidRole(role string) bool {
+	_, ok := roles[role]
+	return ok
+}
+
+// Permissions returns the permissions granted to a role
+func Permissions(role string) []Permission {
+	return slices.Clone(roles[Normalize(role)])
+}
+
+// Can reports whether a role grants every given permission
+func Can(role string, perms ...Permission) bool {
+	granted := roles[Normalize(role)]
+	for _, perm := range perms {
+		if !slices.Contains(granted, perm) {
+			return false
+		}
+	}
+	return true
+}
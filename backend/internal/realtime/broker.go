@@ -0,0 +1,137 @@
+// Package realtime pushes court availability changes to connected browsers.
+package realtime
+
+import (
+	"log"
+	"sync"
+)
+
+// Event types
+const (
+	EventBookingCreated   = "booking_created"
+	EventBookingCancelled = "booking_cancelled"
+	EventBookingReleased  = "booking_released" // no-show ปล่อยคอร์ทคืน
+	EventCourtStatus      = "court_status"
+)
+
+// Event is a change in court availability at a venue.
+// Events without a Date (such as court status changes) apply to every date.
+type Event struct {
+	Type        string `json:"type"`
+	VenueID     string `json:"venueId"`
+	Date        string `json:"date,omitempty"` // Format: YYYY-MM-DD
+	CourtNumber int    `json:"courtNumber"`
+	StartTime   string `json:"startTime,omitempty"` // Format: HH:MM
+	EndTime     string `json:"endTime,omitempty"`   // Format: HH:MM
+	IsActive    *bool  `json:"isActive,omitempty"`
+}
+
+// Relay forwards events to other backend instances.
+// A multi-instance deployment implements it on top of a shared channel
+// (Redis pub/sub, a MongoDB change stream, ...) and calls Broker.Deliver
+// for every event it receives from the other instances.
+type Relay interface {
+	Forward(Event) error
+}
+
+// subscriberBuffer is how many events a slow client may fall behind before it is disconnected
+const subscriberBuffer = 32
+
+// Subscription receives the events of one venue for one date
+type Subscription struct {
+	venueID string
+	date    string
+	events  chan Event
+	broker  *Broker
+}
+
+// Events returns the channel of events. It is closed when the subscription ends,
+// including when the client fell too far behind; the client should then reconnect and reload.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.broker.remove(s)
+}
+
+// Broker fans events out to the subscriptions of this process
+type Broker struct {
+	mu    sync.Mutex
+	subs  map[*Subscription]struct{}
+	relay Relay
+}
+
+// NewBroker creates a broker with no relay
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[*Subscription]struct{})}
+}
+
+// SetRelay forwards every published event to other instances through relay
+func (b *Broker) SetRelay(relay Relay) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.relay = relay
+}
+
+// Subscribe starts receiving events of a venue for date (YYYY-MM-DD)
+func (b *Broker) Subscribe(venueID, date string) *Subscription {
+	sub := &Subscription{
+		venueID: venueID,
+		date:    date,
+		events:  make(chan Event, subscriberBuffer),
+		broker:  b,
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Publish delivers an event to local subscribers and forwards it to other instances
+func (b *Broker) Publish(e Event) {
+	b.Deliver(e)
+
+	b.mu.Lock()
+	relay := b.relay
+	b.mu.Unlock()
+
+	if relay != nil {
+		if err := relay.Forward(e); err != nil {
+			log.Printf("Error forwarding %s event: %v", e.Type, err)
+		}
+	}
+}
+
+// Deliver delivers an event to local subscribers only.
+// Relays call it for events that were published by another instance.
+func (b *Broker) Deliver(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		if e.VenueID != sub.venueID || (e.Date != "" && e.Date != sub.date) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			// client ตามไม่ทัน ตัดการเชื่อมต่อเพื่อให้ reconnect แล้วโหลดข้อมูลใหม่ทั้งหมด
+			delete(b.subs, sub)
+			close(sub.events)
+		}
+	}
+}
+
+func (b *Broker) remove(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.events)
+	}
+}
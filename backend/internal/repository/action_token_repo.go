@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// Action token purposes
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+)
+
+// ActionTokenRepository handles all database operations related to single-use action tokens
+type ActionTokenRepository struct {
+	clocked
+	collection *mongo.Collection
+}
+
+// NewActionTokenRepository creates a new action token repository
+func NewActionTokenRepository(db *mongo.Database) *ActionTokenRepository {
+	return &ActionTokenRepository{
+		collection: db.Collection("action_tokens"),
+	}
+}
+
+// EnsureIndexes creates the indexes the action token repository relies on
+func (r *ActionTokenRepository) EnsureIndexes(ctx context.Context) error {
+	// ลบ token ที่หมดอายุแล้วอัตโนมัติ
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Create stores a new token. Earlier unused tokens of the same user and purpose are
+// invalidated, so only the most recently emailed link works.
+func (r *ActionTokenRepository) Create(ctx context.Context, token *models.ActionToken) error {
+	now := r.now()
+	filter := bson.M{
+		"user_id": token.UserID,
+		"purpose": token.Purpose,
+		"used_at": bson.M{"$exists": false},
+	}
+	if _, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"used_at": now}}); err != nil {
+		return err
+	}
+
+	token.CreatedAt = now
+	_, err := r.collection.InsertOne(ctx, token)
+	return err
+}
+
+// CountIssuedSince counts the tokens of a purpose issued to a user since the given time
+func (r *ActionTokenRepository) CountIssuedSince(ctx context.Context, userID primitive.ObjectID, purpose string, since time.Time) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{
+		"user_id":    userID,
+		"purpose":    purpose,
+		"created_at": bson.M{"$gte": since},
+	})
+}
+
+// CountIssuedToIPSince counts the tokens of a purpose requested from an IP address since the given time
+func (r *ActionTokenRepository) CountIssuedToIPSince(ctx context.Context, ipAddress string, purpose string, since time.Time) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{
+		"ip_address": ipAddress,
+		"purpose":    purpose,
+		"created_at": bson.M{"$gte": since},
+	})
+}
+
+// Consume marks a token as used and returns it. It returns mongo.ErrNoDocuments if the
+// token does not exist, has expired, was already used, or was issued for another purpose.
+func (r *ActionTokenRepository) Consume(ctx context.Context, id primitive.ObjectID, purpose string, tokenHash string) (*models.ActionToken, error) {
+	filter := bson.M{
+		"_id":        id,
+		"purpose":    purpose,
+		"token_hash": tokenHash,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": r.now()},
+	}
+	update := bson.M{"$set": bson.M{"used_at": r.now()}}
+
+	var token models.ActionToken
+	err := r.collection.FindOneAndUpdate(ctx, filter, update).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
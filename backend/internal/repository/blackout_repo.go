@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// BlackoutRepository handles all database operations related to court blackout windows
+type BlackoutRepository struct {
+	clocked
+	collection *mongo.Collection
+}
+
+// NewBlackoutRepository creates a new blackout repository
+func NewBlackoutRepository(db *mongo.Database) *BlackoutRepository {
+	return &BlackoutRepository{
+		collection: db.Collection("blackouts"),
+	}
+}
+
+// Create creates a new blackout window
+func (r *BlackoutRepository) Create(ctx context.Context, blackout *models.Blackout) error {
+	blackout.CreatedAt = r.now()
+
+	_, err := r.collection.InsertOne(ctx, blackout)
+	return err
+}
+
+// FindByID finds a blackout window by ID
+func (r *BlackoutRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Blackout, error) {
+	var blackout models.Blackout
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&blackout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &blackout, nil
+}
+
+// FindUpcoming finds blackout windows of the given venues that have not ended yet.
+// No venues means every venue.
+func (r *BlackoutRepository) FindUpcoming(ctx context.Context, venueIDs []primitive.ObjectID) ([]*models.Blackout, error) {
+	filter := inVenues(bson.M{"end_time": bson.M{"$gt": r.now()}}, venueIDs)
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	blackouts := []*models.Blackout{}
+	if err := cursor.All(ctx, &blackouts); err != nil {
+		return nil, err
+	}
+
+	return blackouts, nil
+}
+
+// FindOverlapping finds blackout windows of a venue that overlap the specified time,
+// optionally limited to one court (courtNumber 0 means all courts)
+func (r *BlackoutRepository) FindOverlapping(ctx context.Context, venueID primitive.ObjectID, courtNumber int, startTime time.Time, endTime time.Time) ([]*models.Blackout, error) {
+	filter := bson.M{
+		"venue_id":   venueID,
+		"start_time": bson.M{"$lt": endTime},
+		"end_time":   bson.M{"$gt": startTime},
+	}
+	if courtNumber != 0 {
+		filter["court_numbers"] = courtNumber
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	blackouts := []*models.Blackout{}
+	if err := cursor.All(ctx, &blackouts); err != nil {
+		return nil, err
+	}
+
+	return blackouts, nil
+}
+
+// HasOverlap checks if a court has a blackout window overlapping the specified time
+func (r *BlackoutRepository) HasOverlap(ctx context.Context, venueID primitive.ObjectID, courtNumber int, startTime time.Time, endTime time.Time) (bool, error) {
+	filter := bson.M{
+		"venue_id":      venueID,
+		"court_numbers": courtNumber,
+		"start_time":    bson.M{"$lt": endTime},
+		"end_time":      bson.M{"$gt": startTime},
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// Delete deletes a blackout window
+func (r *BlackoutRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
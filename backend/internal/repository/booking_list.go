@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// BookingSortFields maps the sort names accepted by the API to booking fields
+var BookingSortFields = map[string]string{
+	"startTime":   "start_time",
+	"createdAt":   "created_at",
+	"courtNumber": "court_number",
+}
+
+// BookingListFilter holds the filters, sort order and page for listing bookings
+type BookingListFilter struct {
+	DateFrom    *time.Time           // วันที่จองเริ่มต้น (รวมวันนั้นด้วย)
+	DateTo      *time.Time           // วันที่จองสิ้นสุด (รวมวันนั้นด้วย)
+	VenueIDs    []primitive.ObjectID // ว่าง = ทุกสนาม
+	CourtNumber int                  // 0 = ทุกคอร์ท
+	StudentID   string
+	Status      string // active, cancelled, completed, no_show หรือว่างสำหรับทุกสถานะ
+	SortBy      string // key ของ BookingSortFields, ค่าเริ่มต้นคือ startTime
+	Descending  bool
+	Cursor      string
+	Limit       int
+}
+
+// bookingCursor is the position after the last booking of a page
+type bookingCursor struct {
+	Value interface{}        `bson:"v"`
+	ID    primitive.ObjectID `bson:"id"`
+}
+
+// FindAll finds bookings matching the filter, one page at a time.
+// It returns the page and the cursor for the next page, which is empty on the last page.
+func (r *BookingRepository) FindAll(ctx context.Context, f BookingListFilter) ([]*models.Booking, string, error) {
+	sortField, ok := BookingSortFields[f.SortBy]
+	if !ok {
+		sortField = BookingSortFields["startTime"]
+	}
+	direction := 1
+	compare := "$gt"
+	if f.Descending {
+		direction = -1
+		compare = "$lt"
+	}
+
+	filter := inVenues(bson.M{}, f.VenueIDs)
+	if f.DateFrom != nil || f.DateTo != nil {
+		dateRange := bson.M{}
+		if f.DateFrom != nil {
+			dateRange["$gte"] = *f.DateFrom
+		}
+		if f.DateTo != nil {
+			dateRange["$lt"] = f.DateTo.AddDate(0, 0, 1)
+		}
+		filter["booking_date"] = dateRange
+	}
+	if f.CourtNumber != 0 {
+		filter["court_number"] = f.CourtNumber
+	}
+	if f.StudentID != "" {
+		filter["student_id"] = f.StudentID
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+
+	// เริ่มหน้าถัดไปต่อจากตำแหน่งใน cursor โดยใช้ _id ตัดสินเมื่อค่าที่ sort เท่ากัน
+	if f.Cursor != "" {
+		cur, err := decodeBookingCursor(f.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		filter["$or"] = []bson.M{
+			{sortField: bson.M{compare: cur.Value}},
+			{sortField: cur.Value, "_id": bson.M{compare: cur.ID}},
+		}
+	}
+
+	// ดึงเกินมาหนึ่งรายการเพื่อดูว่ายังมีหน้าถัดไปหรือไม่
+	opts := options.Find().
+		SetSort(bson.D{{Key: sortField, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(int64(f.Limit + 1))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []*models.Booking{}
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, "", err
+	}
+
+	if len(bookings) <= f.Limit {
+		return bookings, "", nil
+	}
+
+	bookings = bookings[:f.Limit]
+	next, err := encodeBookingCursor(bookings[len(bookings)-1], sortField)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return bookings, next, nil
+}
+
+func encodeBookingCursor(last *models.Booking, sortField string) (string, error) {
+	cur := bookingCursor{ID: last.ID}
+	switch sortField {
+	case "created_at":
+		cur.Value = last.CreatedAt
+	case "court_number":
+		cur.Value = last.CourtNumber
+	default:
+		cur.Value = last.StartTime
+	}
+
+	raw, err := bson.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeBookingCursor(s string) (*bookingCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cur bookingCursor
+	if err := bson.Unmarshal(raw, &cur); err != nil || cur.ID.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &cur, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"log"
 	"time"
 
@@ -13,28 +14,263 @@ import (
 	"courtopia-reserve/backend/internal/models"
 )
 
+// ErrSlotUnavailable is returned when the requested time overlaps an active booking
+var ErrSlotUnavailable = errors.New("court is not available for the selected time")
+
+// ErrCourtUnavailable is returned when the court was closed or archived while it was being booked
+var ErrCourtUnavailable = errors.New("court is not open for booking")
+
+// ErrBookingChanged is returned when a booking was cancelled or moved while it was being changed
+var ErrBookingChanged = errors.New("booking was changed by someone else")
+
 // BookingRepository handles all database operations related to bookings
 type BookingRepository struct {
-	collection *mongo.Collection
+	clocked
+	collection   *mongo.Collection
+	locks        *mongo.Collection
+	courts       *mongo.Collection
+	outbox       *mongo.Collection
+	blackouts    *BlackoutRepository
+	transactions bool
 }
 
 // NewBookingRepository creates a new booking repository
 func NewBookingRepository(db *mongo.Database) *BookingRepository {
 	return &BookingRepository{
 		collection: db.Collection("bookings"),
+		locks:      db.Collection("slot_locks"),
+		courts:     db.Collection("courts"),
+		outbox:     db.Collection("outbox"),
+		blackouts:  NewBlackoutRepository(db),
+	}
+}
+
+// UseTransactions makes booking changes and their outbox messages commit in one
+// multi-document transaction. Only enable it when the server is a replica set or mongos.
+func (r *BookingRepository) UseTransactions(enabled bool) {
+	r.transactions = enabled
+}
+
+// withTransaction runs fn inside a transaction when transactions are enabled,
+// otherwise it runs fn directly
+func (r *BookingRepository) withTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !r.transactions {
+		return fn(ctx)
+	}
+
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
 	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// enqueue writes outbox messages as part of the current booking change
+func (r *BookingRepository) enqueue(ctx context.Context, messages []*models.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	now := r.now()
+	docs := make([]interface{}, len(messages))
+	for i, msg := range messages {
+		stampOutboxMessage(msg, now)
+		docs[i] = msg
+	}
+
+	_, err := r.outbox.InsertMany(ctx, docs)
+	return err
 }
 
 // Create creates a new booking
 func (r *BookingRepository) Create(ctx context.Context, booking *models.Booking) error {
-	booking.CreatedAt = time.Now()
-	booking.UpdatedAt = time.Now()
+	booking.CreatedAt = r.now()
+	booking.UpdatedAt = r.now()
 	booking.Status = "active"
 
 	_, err := r.collection.InsertOne(ctx, booking)
 	return err
 }
 
+// CreateIfAvailable creates a booking only if no active booking overlaps it.
+// The overlap check and the insert run while holding the court/day slot lock,
+// so two concurrent requests for the same slot cannot both succeed.
+// The given outbox messages are written together with the booking.
+func (r *BookingRepository) CreateIfAvailable(ctx context.Context, booking *models.Booking, outbox ...*models.OutboxMessage) error {
+	release, err := r.lockCourtDay(ctx, booking.CourtID, booking.BookingDate)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if booking.ID.IsZero() {
+		booking.ID = primitive.NewObjectID()
+	}
+	for _, msg := range outbox {
+		msg.BookingID = booking.ID
+	}
+
+	return r.withTransaction(ctx, func(ctx context.Context) error {
+		isAvailable, err := r.IsCourtAvailable(ctx, booking.VenueID, booking.CourtNumber, booking.BookingDate, booking.StartTime, booking.EndTime)
+		if err != nil {
+			return err
+		}
+		if !isAvailable {
+			return ErrSlotUnavailable
+		}
+
+		if err := r.Create(ctx, booking); err != nil {
+			return err
+		}
+		if err := r.claimCourt(ctx, booking.CourtID); err != nil {
+			// ถ้าไม่ได้ใช้ transaction การจองถูกบันทึกไปแล้ว ต้องลบออกเอง
+			if errors.Is(err, ErrCourtUnavailable) {
+				if _, derr := r.collection.DeleteOne(ctx, bson.M{"_id": booking.ID}); derr != nil {
+					return derr
+				}
+			}
+			return err
+		}
+		return r.enqueue(ctx, outbox)
+	})
+}
+
+// claimCourt records that a booking was just written to a court, and returns ErrCourtUnavailable
+// if the court is closed or archived. It must run after the booking is written: archiving marks the
+// court first and then looks for upcoming bookings, so either archiving sees the new booking or the
+// booking sees the archived court. Inside a transaction the write to the court document also makes a
+// concurrent archive conflict with the transaction instead of missing its uncommitted booking.
+func (r *BookingRepository) claimCourt(ctx context.Context, courtID primitive.ObjectID) error {
+	filter := bson.M{"_id": courtID, "is_active": true, "archived_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"last_booked_at": r.now()}}
+
+	result, err := r.courts.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCourtUnavailable
+	}
+	return nil
+}
+
+// Reschedule moves an active booking to change.To if no other active booking or blackout
+// overlaps the new slot. Like CreateIfAvailable it checks and writes while holding the slot
+// lock of the new court and day. The old slot is given up by the same update that takes the
+// new one, so the booking never loses its court if the move fails.
+// The change is appended to the booking's history and the given outbox messages are written with it.
+func (r *BookingRepository) Reschedule(ctx context.Context, booking *models.Booking, change models.BookingChange, outbox ...*models.OutboxMessage) error {
+	to := change.To
+	release, err := r.lockCourtDay(ctx, to.CourtID, to.BookingDate)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	for _, msg := range outbox {
+		msg.BookingID = booking.ID
+	}
+
+	err = r.withTransaction(ctx, func(ctx context.Context) error {
+		// การจองนี้เองไม่นับเป็นการจองที่ทับ เพื่อให้เลื่อนเวลาบนคอร์ทเดิมได้
+		overlap := overlapFilter(booking.VenueID, to.CourtNumber, to.BookingDate, to.StartTime, to.EndTime)
+		overlap["_id"] = bson.M{"$ne": booking.ID}
+		count, err := r.collection.CountDocuments(ctx, overlap)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrSlotUnavailable
+		}
+
+		blocked, err := r.blackouts.HasOverlap(ctx, booking.VenueID, to.CourtNumber, to.StartTime, to.EndTime)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return ErrSlotUnavailable
+		}
+
+		// ย้ายได้เฉพาะเมื่อการจองยังอยู่ที่เดิม ถ้ามีคนยกเลิกหรือย้ายไปก่อนจะไม่ match
+		filter := bson.M{
+			"_id":        booking.ID,
+			"status":     "active",
+			"court_id":   change.From.CourtID,
+			"start_time": change.From.StartTime,
+			"end_time":   change.From.EndTime,
+		}
+		update := bson.M{
+			"$set": bson.M{
+				"court_id":          to.CourtID,
+				"court_number":      to.CourtNumber,
+				"booking_date":      to.BookingDate,
+				"start_time":        to.StartTime,
+				"end_time":          to.EndTime,
+				"notification_sent": false, // เตือนใหม่ตามเวลาใหม่
+				"updated_at":        change.ChangedAt,
+			},
+			"$push": bson.M{"changes": change},
+		}
+
+		result, err := r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrBookingChanged
+		}
+		if err := r.claimCourt(ctx, to.CourtID); err != nil {
+			// ถ้าไม่ได้ใช้ transaction การจองถูกย้ายไปแล้ว ต้องย้ายกลับที่เดิมเอง
+			if errors.Is(err, ErrCourtUnavailable) {
+				if uerr := r.undoReschedule(ctx, booking, change); uerr != nil {
+					return uerr
+				}
+			}
+			return err
+		}
+		return r.enqueue(ctx, outbox)
+	})
+	if err != nil {
+		return err
+	}
+
+	booking.CourtID = to.CourtID
+	booking.CourtNumber = to.CourtNumber
+	booking.BookingDate = to.BookingDate
+	booking.StartTime = to.StartTime
+	booking.EndTime = to.EndTime
+	booking.NotificationSent = false
+	booking.UpdatedAt = change.ChangedAt
+	booking.Changes = append(booking.Changes, change)
+	return nil
+}
+
+// undoReschedule moves a booking back to change.From and drops change from its history
+func (r *BookingRepository) undoReschedule(ctx context.Context, booking *models.Booking, change models.BookingChange) error {
+	from := change.From
+	filter := bson.M{"_id": booking.ID, "court_id": change.To.CourtID, "start_time": change.To.StartTime}
+	update := bson.M{
+		"$set": bson.M{
+			"court_id":          from.CourtID,
+			"court_number":      from.CourtNumber,
+			"booking_date":      from.BookingDate,
+			"start_time":        from.StartTime,
+			"end_time":          from.EndTime,
+			"notification_sent": booking.NotificationSent,
+			"updated_at":        booking.UpdatedAt,
+		},
+		"$pop": bson.M{"changes": 1},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
 // FindByID finds a booking by ID
 func (r *BookingRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Booking, error) {
 	var booking models.Booking
@@ -88,7 +324,7 @@ func (r *BookingRepository) FindActiveBookingsByStudentID(ctx context.Context, s
 	filter := bson.M{
 		"student_id": studentID,
 		"status":     "active",
-		"end_time":   bson.M{"$gte": time.Now()},
+		"end_time":   bson.M{"$gte": r.now()},
 	}
 
 	// แก้ไขส่วนนี้: เปลี่ยนจาก bson.M เป็น bson.D
@@ -111,9 +347,58 @@ func (r *BookingRepository) FindActiveBookingsByStudentID(ctx context.Context, s
 	return bookings, nil
 }
 
+// FindCalendarBookingsByStudentID finds a user's bookings that have not ended yet, both active and cancelled.
+// Cancelled bookings are kept so calendar clients can remove events they already imported.
+func (r *BookingRepository) FindCalendarBookingsByStudentID(ctx context.Context, studentID string) ([]*models.Booking, error) {
+	filter := bson.M{
+		"student_id": studentID,
+		"status":     bson.M{"$in": []string{"active", "cancelled"}},
+		"end_time":   bson.M{"$gte": r.now()},
+	}
+	opts := options.Find().SetSort(bson.D{
+		{Key: "booking_date", Value: 1},
+		{Key: "start_time", Value: 1},
+	})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []*models.Booking{}
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// FindUserBookingsBetween finds a user's active and completed bookings that start within [from, to)
+func (r *BookingRepository) FindUserBookingsBetween(ctx context.Context, studentID string, from time.Time, to time.Time) ([]*models.Booking, error) {
+	filter := bson.M{
+		"student_id": studentID,
+		"status":     bson.M{"$in": []string{"active", "completed"}},
+		"start_time": bson.M{"$gte": from, "$lt": to},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []*models.Booking{}
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
 // Update updates an existing booking
 func (r *BookingRepository) Update(ctx context.Context, booking *models.Booking) error {
-	booking.UpdatedAt = time.Now()
+	booking.UpdatedAt = r.now()
 
 	filter := bson.M{"_id": booking.ID}
 	update := bson.M{"$set": booking}
@@ -122,139 +407,436 @@ func (r *BookingRepository) Update(ctx context.Context, booking *models.Booking)
 	return err
 }
 
-// CancelBooking cancels a booking by updating its status
-func (r *BookingRepository) CancelBooking(ctx context.Context, id primitive.ObjectID) error {
-	filter := bson.M{"_id": id}
+// CancelBooking cancels an active booking and records who cancelled it and why.
+// It returns ErrBookingChanged if the booking is no longer active.
+// The given outbox messages are written together with the status change.
+func (r *BookingRepository) CancelBooking(ctx context.Context, id primitive.ObjectID, cancellation models.BookingCancellation, outbox ...*models.OutboxMessage) error {
+	filter := bson.M{"_id": id, "status": "active"}
 	update := bson.M{"$set": bson.M{
-		"status":     "cancelled",
-		"updated_at": time.Now(),
+		"status":       "cancelled",
+		"cancellation": cancellation,
+		"updated_at":   cancellation.CancelledAt,
 	}}
 
-	_, err := r.collection.UpdateOne(ctx, filter, update)
-	return err
+	return r.withTransaction(ctx, func(ctx context.Context) error {
+		result, err := r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrBookingChanged
+		}
+		return r.enqueue(ctx, outbox)
+	})
 }
 
-// IsCourtAvailable checks if a court is available at the specified time
-func (r *BookingRepository) IsCourtAvailable(ctx context.Context, courtNumber int, bookingDate time.Time, startTime time.Time, endTime time.Time) (bool, error) {
+// overlapFilter matches active bookings on a court that overlap the given time window
+func overlapFilter(venueID primitive.ObjectID, courtNumber int, bookingDate time.Time, startTime time.Time, endTime time.Time) bson.M {
 	// Create dates for the start and end of the booking day
 	startOfDay := time.Date(bookingDate.Year(), bookingDate.Month(), bookingDate.Day(), 0, 0, 0, 0, bookingDate.Location())
 	endOfDay := time.Date(bookingDate.Year(), bookingDate.Month(), bookingDate.Day(), 23, 59, 59, 999999999, bookingDate.Location())
 
-	// Check for overlapping bookings
-	filter := bson.M{
+	return bson.M{
+		"venue_id":     venueID,
 		"court_number": courtNumber,
 		"booking_date": bson.M{
 			"$gte": startOfDay,
 			"$lte": endOfDay,
 		},
-		"status": "active",
-		"$or": []bson.M{
-			{
-				"start_time": bson.M{"$lte": startTime},
-				"end_time":   bson.M{"$gt": startTime},
-			},
-			{
-				"start_time": bson.M{"$lt": endTime},
-				"end_time":   bson.M{"$gte": endTime},
-			},
-			{
-				"start_time": bson.M{"$gte": startTime},
-				"end_time":   bson.M{"$lte": endTime},
-			},
+		"status":     "active",
+		"start_time": bson.M{"$lt": endTime},
+		"end_time":   bson.M{"$gt": startTime},
+	}
+}
+
+// FindBySeriesID finds all occurrences of a booking series
+func (r *BookingRepository) FindBySeriesID(ctx context.Context, seriesID primitive.ObjectID) ([]*models.Booking, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"series_id": seriesID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []*models.Booking{}
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// FindActiveSeriesBookings finds the active occurrences of a series that start at or after from
+func (r *BookingRepository) FindActiveSeriesBookings(ctx context.Context, seriesID primitive.ObjectID, from time.Time) ([]*models.Booking, error) {
+	filter := bson.M{
+		"series_id":  seriesID,
+		"status":     "active",
+		"start_time": bson.M{"$gte": from},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []*models.Booking{}
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// transitionMany moves every booking matching filter from active to status one at a time,
+// so each booking is reported exactly once even if another request changes it concurrently
+func (r *BookingRepository) transitionMany(ctx context.Context, filter bson.M, status string) ([]*models.Booking, error) {
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var candidates []*models.Booking
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	changed := []*models.Booking{}
+	for _, booking := range candidates {
+		now := r.now()
+		update := bson.M{"$set": bson.M{
+			"status":     status,
+			"updated_at": now,
+		}}
+
+		result, err := r.collection.UpdateOne(ctx, bson.M{"_id": booking.ID, "status": "active"}, update)
+		if err != nil {
+			return changed, err
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+
+		booking.Status = status
+		booking.UpdatedAt = now
+		changed = append(changed, booking)
+	}
+
+	return changed, nil
+}
+
+// CheckIn records that the players of an active booking have arrived.
+// It returns false if the booking is not active or was already checked in.
+func (r *BookingRepository) CheckIn(ctx context.Context, id primitive.ObjectID, checkedInBy string) (bool, error) {
+	filter := bson.M{
+		"_id":           id,
+		"status":        "active",
+		"checked_in_at": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{
+		"checked_in_at": r.now(),
+		"checked_in_by": checkedInBy,
+		"updated_at":    r.now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// FindNoShowCandidates finds active bookings that started between startedAfter and startedBefore
+// and were never checked in
+func (r *BookingRepository) FindNoShowCandidates(ctx context.Context, startedAfter, startedBefore time.Time) ([]*models.Booking, error) {
+	filter := bson.M{
+		"status":        "active",
+		"start_time":    bson.M{"$gte": startedAfter, "$lt": startedBefore},
+		"checked_in_at": bson.M{"$exists": false},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []*models.Booking{}
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// MarkNoShow releases a booking nobody checked in to.
+// It returns false if the booking was checked in or changed in the meantime.
+func (r *BookingRepository) MarkNoShow(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id":           id,
+		"status":        "active",
+		"checked_in_at": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":     "no_show",
+		"updated_at": r.now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// CountNoShowsSince counts a user's no-show and late-cancelled bookings that started at or after since
+func (r *BookingRepository) CountNoShowsSince(ctx context.Context, studentID string, since time.Time) (int64, error) {
+	filter := bson.M{
+		"student_id": studentID,
+		"$or": bson.A{
+			bson.M{"status": "no_show"},
+			bson.M{"status": "cancelled", "cancellation.late": true},
 		},
+		"start_time": bson.M{"$gte": since},
 	}
 
-	count, err := r.collection.CountDocuments(ctx, filter)
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+// IsCourtAvailable checks if a court is available at the specified time.
+// Blackout windows count as occupied.
+func (r *BookingRepository) IsCourtAvailable(ctx context.Context, venueID primitive.ObjectID, courtNumber int, bookingDate time.Time, startTime time.Time, endTime time.Time) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, overlapFilter(venueID, courtNumber, bookingDate, startTime, endTime))
 	if err != nil {
 		return false, err
 	}
+	if count > 0 {
+		return false, nil
+	}
+
+	blocked, err := r.blackouts.HasOverlap(ctx, venueID, courtNumber, startTime, endTime)
+	if err != nil {
+		return false, err
+	}
+
+	return !blocked, nil
+}
+
+// FindActiveInWindow finds active bookings on any of the given courts of a venue that overlap the specified time
+func (r *BookingRepository) FindActiveInWindow(ctx context.Context, venueID primitive.ObjectID, courtNumbers []int, startTime time.Time, endTime time.Time) ([]*models.Booking, error) {
+	filter := bson.M{
+		"venue_id":     venueID,
+		"court_number": bson.M{"$in": courtNumbers},
+		"status":       "active",
+		"start_time":   bson.M{"$lt": endTime},
+		"end_time":     bson.M{"$gt": startTime},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}, {Key: "court_number", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []*models.Booking{}
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// FindUpcomingByCourt finds the active bookings on a court of a venue that have not ended yet
+func (r *BookingRepository) FindUpcomingByCourt(ctx context.Context, venueID primitive.ObjectID, courtNumber int) ([]*models.Booking, error) {
+	filter := bson.M{
+		"venue_id":     venueID,
+		"court_number": courtNumber,
+		"status":       "active",
+		"end_time":     bson.M{"$gt": r.now()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []*models.Booking{}
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, err
+	}
 
-	return count == 0, nil
+	return bookings, nil
 }
 
-// GetAvailableCourts returns all available courts at the specified time
-func (r *BookingRepository) GetAvailableCourts(ctx context.Context, bookingDate time.Time, startTime time.Time, endTime time.Time, courtRepo *CourtRepository) ([]*models.CourtAvailability, error) {
+// FindConflicts finds the active bookings on a court that overlap the specified time
+func (r *BookingRepository) FindConflicts(ctx context.Context, venueID primitive.ObjectID, courtNumber int, bookingDate time.Time, startTime time.Time, endTime time.Time) ([]*models.Booking, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, overlapFilter(venueID, courtNumber, bookingDate, startTime, endTime), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []*models.Booking{}
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// BookedInterval is the time window of an active booking
+type BookedInterval struct {
+	StartTime time.Time `bson:"start_time"`
+	EndTime   time.Time `bson:"end_time"`
+}
+
+// dayRange returns the first instant of the booking day and of the day after it
+func dayRange(bookingDate time.Time) (time.Time, time.Time) {
+	startOfDay := time.Date(bookingDate.Year(), bookingDate.Month(), bookingDate.Day(), 0, 0, 0, 0, bookingDate.Location())
+	return startOfDay, startOfDay.AddDate(0, 0, 1)
+}
+
+// GetAvailableCourts returns all available courts of a venue at the specified time
+func (r *BookingRepository) GetAvailableCourts(ctx context.Context, venueID primitive.ObjectID, bookingDate time.Time, startTime time.Time, endTime time.Time, courtRepo *CourtRepository) ([]*models.CourtAvailability, error) {
 	// Get all active courts
-	courts, err := courtRepo.FindActiveCourts(ctx)
+	courts, err := courtRepo.FindActiveCourts(ctx, venueID)
 	if err != nil {
 		return nil, err
 	}
 
-	var availabilities []*models.CourtAvailability
+	// หาเลขคอร์ทที่มีการจองทับช่วงเวลานี้ในคำสั่งเดียว แทนการนับทีละคอร์ท
+	startOfDay, nextDay := dayRange(bookingDate)
+	filter := bson.M{
+		"venue_id":     venueID,
+		"booking_date": bson.M{"$gte": startOfDay, "$lt": nextDay},
+		"status":       "active",
+		"start_time":   bson.M{"$lt": endTime},
+		"end_time":     bson.M{"$gt": startTime},
+	}
+	booked, err := r.collection.Distinct(ctx, "court_number", filter)
+	if err != nil {
+		return nil, err
+	}
 
-	// Check availability for each court
-	for _, court := range courts {
-		isAvailable, err := r.IsCourtAvailable(ctx, court.CourtNumber, bookingDate, startTime, endTime)
-		if err != nil {
-			return nil, err
+	bookedCourts := make(map[int]bool, len(booked))
+	for _, v := range booked {
+		switch n := v.(type) {
+		case int32:
+			bookedCourts[int(n)] = true
+		case int64:
+			bookedCourts[int(n)] = true
 		}
+	}
 
+	// คอร์ทที่อยู่ในช่วงปิดปรับปรุงถือว่าไม่ว่าง
+	blackouts, err := r.blackouts.FindOverlapping(ctx, venueID, 0, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	for _, blackout := range blackouts {
+		for _, courtNumber := range blackout.CourtNumbers {
+			bookedCourts[courtNumber] = true
+		}
+	}
+
+	var availabilities []*models.CourtAvailability
+	for _, court := range courts {
 		availabilities = append(availabilities, &models.CourtAvailability{
 			CourtNumber: court.CourtNumber,
-			IsAvailable: isAvailable,
+			IsAvailable: !bookedCourts[court.CourtNumber],
 		})
 	}
 
 	return availabilities, nil
 }
 
-// เพิ่มฟังก์ชันใหม่เพื่อตรวจสอบและอัปเดตสถานะการจองที่สิ้นสุดแล้ว
-func (r *BookingRepository) UpdateCompletedBookings(ctx context.Context) error {
-	now := time.Now()
+// FindDaySchedule returns the active booking windows of every court of a venue on a day,
+// keyed by court number and sorted by start time, using a single aggregation
+func (r *BookingRepository) FindDaySchedule(ctx context.Context, venueID primitive.ObjectID, bookingDate time.Time) (map[int][]BookedInterval, error) {
+	startOfDay, nextDay := dayRange(bookingDate)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"venue_id":     venueID,
+			"booking_date": bson.M{"$gte": startOfDay, "$lt": nextDay},
+			"status":       "active",
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "start_time", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": "$court_number",
+			"intervals": bson.M{"$push": bson.M{
+				"start_time": "$start_time",
+				"end_time":   "$end_time",
+			}},
+		}}},
+	}
 
-	// ค้นหาการจองที่กำลังใช้งานอยู่แต่เวลาสิ้นสุดผ่านไปแล้ว
-	filter := bson.M{
-		"status":   "active",
-		"end_time": bson.M{"$lt": now},
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	update := bson.M{
-		"$set": bson.M{
-			"status":     "completed",
-			"updated_at": time.Now(),
-		},
+	var groups []struct {
+		CourtNumber int              `bson:"_id"`
+		Intervals   []BookedInterval `bson:"intervals"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
 	}
 
-	// อัปเดตหลายรายการพร้อมกัน
-	_, err := r.collection.UpdateMany(ctx, filter, update)
-	return err
+	schedule := make(map[int][]BookedInterval, len(groups))
+	for _, g := range groups {
+		schedule[g.CourtNumber] = g.Intervals
+	}
+
+	return schedule, nil
 }
-func (r *BookingRepository) FindUpcomingBookings(ctx context.Context, beforeTime time.Time) ([]*models.Booking, error) {
-    // ตัดมิลลิวินาทีออกจาก beforeTime
-    beforeTime = beforeTime.Truncate(time.Minute)
 
-    // สร้าง filter โดยใช้ $expr เพื่อเปรียบเทียบเฉพาะชั่วโมงและนาที
-    filter := bson.M{
-        "$expr": bson.M{
-            "$and": []bson.M{
-                {"$lte": []interface{}{
-                    bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d %H:%M", "date": "$start_time"}},
-                    beforeTime.Format("2006-01-02 15:04"),
-                }},
-				{"$eq": []interface{}{"$notification_sent", false}},
-            },
-        },
-    }
+// เพิ่มฟังก์ชันใหม่เพื่อตรวจสอบและอัปเดตสถานะการจองที่สิ้นสุดแล้ว
+// คืนรายการการจองที่เพิ่งถูกเปลี่ยนเป็น completed
+func (r *BookingRepository) UpdateCompletedBookings(ctx context.Context, now time.Time) ([]*models.Booking, error) {
+	// ค้นหาการจองที่กำลังใช้งานอยู่แต่เวลาสิ้นสุดผ่านไปแล้ว
+	filter := bson.M{
+		"status":   "active",
+		"end_time": bson.M{"$lt": now},
+	}
 
-    log.Printf("FindUpcomingBookings filter: %+v", filter) // เพิ่ม log เพื่อดู filter ที่ใช้
+	return r.transitionMany(ctx, filter, "completed")
+}
 
-    var bookings []*models.Booking
-    cursor, err := r.collection.Find(ctx, filter)
-    if err != nil {
-        log.Printf("Error fetching upcoming bookings: %v", err)
-        return nil, err
-    }
-    defer cursor.Close(ctx)
+// FindUpcomingBookings finds active bookings starting after from and no later than to
+// whose reminder has not been sent. start_time is a real instant, so the server's timezone does not matter.
+func (r *BookingRepository) FindUpcomingBookings(ctx context.Context, from, to time.Time) ([]*models.Booking, error) {
+	filter := bson.M{
+		"status":            "active",
+		"notification_sent": false,
+		"start_time":        bson.M{"$gt": from, "$lte": to},
+	}
 
-    err = cursor.All(ctx, &bookings)
-    if err != nil {
-        log.Printf("Error decoding bookings: %v", err)
-        return nil, err
-    }
+	var bookings []*models.Booking
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		log.Printf("Error fetching upcoming bookings: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
 
-    log.Printf("Upcoming bookings found: %+v", bookings) // เพิ่ม log เพื่อดูข้อมูลการจองที่ดึงออกมา
+	err = cursor.All(ctx, &bookings)
+	if err != nil {
+		log.Printf("Error decoding bookings: %v", err)
+		return nil, err
+	}
 
-    return bookings, nil
+	return bookings, nil
 }
 
 // UpdateBooking อัปเดตสถานะการแจ้งเตือน
@@ -270,3 +852,35 @@ func (r *BookingRepository) UpdateBooking(ctx context.Context, booking *models.B
 
 	return nil
 }
+
+// QueueReminder marks a booking's reminder as sent and writes the reminder to the outbox.
+// It returns false if another scheduler run already queued the reminder.
+// A nil reminder only marks the booking, so it is not picked up again.
+func (r *BookingRepository) QueueReminder(ctx context.Context, booking *models.Booking, reminder *models.OutboxMessage) (bool, error) {
+	queued := false
+	err := r.withTransaction(ctx, func(ctx context.Context) error {
+		filter := bson.M{"_id": booking.ID, "notification_sent": false}
+		update := bson.M{"$set": bson.M{"notification_sent": true, "updated_at": r.now()}}
+
+		result, err := r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			queued = false
+			return nil
+		}
+
+		if reminder == nil {
+			return nil
+		}
+		queued = true
+		return r.enqueue(ctx, []*models.OutboxMessage{reminder})
+	})
+	if err != nil {
+		return false, err
+	}
+
+	booking.NotificationSent = true
+	return queued, nil
+}
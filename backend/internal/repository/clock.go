@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"time"
+
+	"courtopia-reserve/backend/internal/clock"
+)
+
+// clocked gives a repository the current time from an injectable clock.
+// Repositories embed it and read the time with r.now().
+type clocked struct {
+	clock clock.Clock
+}
+
+// UseClock makes the repository read the current time from c instead of the system clock
+func (r *clocked) UseClock(c clock.Clock) {
+	r.clock = c
+}
+
+// now returns the current time of the repository's clock
+func (r *clocked) now() time.Time {
+	if r.clock == nil {
+		return clock.System{}.Now()
+	}
+	return r.clock.Now()
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,6 +14,7 @@ import (
 
 // CourtRepository handles all database operations related to courts
 type CourtRepository struct {
+	clocked
 	collection *mongo.Collection
 }
 
@@ -23,12 +25,52 @@ func NewCourtRepository(db *mongo.Database) *CourtRepository {
 	}
 }
 
-// FindAll finds all courts
-func (r *CourtRepository) FindAll(ctx context.Context) ([]*models.Court, error) {
+// legacyCourtNumberIndex is the index that made court numbers unique across all venues
+const legacyCourtNumberIndex = "court_number_1"
+
+// EnsureIndexes creates the indexes the court repository relies on
+func (r *CourtRepository) EnsureIndexes(ctx context.Context) error {
+	// เลขคอร์ทไม่ต้องซ้ำกันข้ามสนามแล้ว ลบ index เดิมทิ้ง (ไม่มีอยู่แล้วก็ไม่เป็นไร)
+	_, err := r.collection.Indexes().DropOne(ctx, legacyCourtNumberIndex)
+	var cmdErr mongo.CommandError // 26 = NamespaceNotFound, 27 = IndexNotFound
+	if err != nil && !(errors.As(err, &cmdErr) && (cmdErr.Code == 26 || cmdErr.Code == 27)) {
+		return err
+	}
+
+	// เลขคอร์ทไม่ซ้ำกันภายในสนาม รวมถึงคอร์ทที่เลิกใช้แล้ว เพราะการจองเก่ายังอ้างอิงเลขนั้นอยู่
+	_, err = r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "venue_id", Value: 1}, {Key: "court_number", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// Create inserts a new court. It returns an error for which mongo.IsDuplicateKeyError
+// is true if the court number is taken in the venue.
+func (r *CourtRepository) Create(ctx context.Context, court *models.Court) error {
+	court.CreatedAt = r.now()
+	court.UpdatedAt = court.CreatedAt
+
+	_, err := r.collection.InsertOne(ctx, court)
+	return err
+}
+
+// FindAll finds all courts of the given venues that have not been archived.
+// No venues means every venue.
+func (r *CourtRepository) FindAll(ctx context.Context, venueIDs []primitive.ObjectID) ([]*models.Court, error) {
+	return r.find(ctx, inVenues(bson.M{"archived_at": bson.M{"$exists": false}}, venueIDs))
+}
+
+// FindAllWithArchived finds all courts of the given venues, including archived ones
+func (r *CourtRepository) FindAllWithArchived(ctx context.Context, venueIDs []primitive.ObjectID) ([]*models.Court, error) {
+	return r.find(ctx, inVenues(bson.M{}, venueIDs))
+}
+
+func (r *CourtRepository) find(ctx context.Context, filter bson.M) ([]*models.Court, error) {
 	var courts []*models.Court
 
-	opts := options.Find().SetSort(bson.M{"court_number": 1})
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	opts := options.Find().SetSort(bson.D{{Key: "venue_id", Value: 1}, {Key: "court_number", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -55,11 +97,11 @@ func (r *CourtRepository) FindByID(ctx context.Context, id primitive.ObjectID) (
 	return &court, nil
 }
 
-// FindByCourtNumber finds a court by its number
-func (r *CourtRepository) FindByCourtNumber(ctx context.Context, courtNumber int) (*models.Court, error) {
+// FindByCourtNumber finds a court of a venue that has not been archived by its number
+func (r *CourtRepository) FindByCourtNumber(ctx context.Context, venueID primitive.ObjectID, courtNumber int) (*models.Court, error) {
 	var court models.Court
 
-	filter := bson.M{"court_number": courtNumber}
+	filter := bson.M{"venue_id": venueID, "court_number": courtNumber, "archived_at": bson.M{"$exists": false}}
 	err := r.collection.FindOne(ctx, filter).Decode(&court)
 	if err != nil {
 		return nil, err
@@ -68,11 +110,11 @@ func (r *CourtRepository) FindByCourtNumber(ctx context.Context, courtNumber int
 	return &court, nil
 }
 
-// FindActiveCourts finds all active courts
-func (r *CourtRepository) FindActiveCourts(ctx context.Context) ([]*models.Court, error) {
+// FindActiveCourts finds all active courts of a venue
+func (r *CourtRepository) FindActiveCourts(ctx context.Context, venueID primitive.ObjectID) ([]*models.Court, error) {
 	var courts []*models.Court
 
-	filter := bson.M{"is_active": true}
+	filter := bson.M{"venue_id": venueID, "is_active": true, "archived_at": bson.M{"$exists": false}}
 	opts := options.Find().SetSort(bson.M{"court_number": 1})
 	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
@@ -96,3 +138,67 @@ func (r *CourtRepository) UpdateStatus(ctx context.Context, id primitive.ObjectI
 	_, err := r.collection.UpdateOne(ctx, filter, update)
 	return err
 }
+
+// Update saves the editable details of a court
+func (r *CourtRepository) Update(ctx context.Context, court *models.Court) error {
+	court.UpdatedAt = r.now()
+
+	filter := bson.M{"_id": court.ID}
+	update := bson.M{"$set": bson.M{
+		"name":         court.Name,
+		"location":     court.Location,
+		"surface_type": court.SurfaceType,
+		"capacity":     court.Capacity,
+		"is_active":    court.IsActive,
+		"updated_at":   court.UpdatedAt,
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// Archive takes a court out of use. The court is kept so past bookings still refer to it.
+// It returns false if the court does not exist or is already archived.
+func (r *CourtRepository) Archive(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := bson.M{"_id": id, "archived_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{
+		"archived_at": r.now(),
+		"is_active":   false,
+		"updated_at":  r.now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// UndoArchive reverts Archive when the court turned out to still have bookings,
+// putting back whether the court was open before it was archived
+func (r *CourtRepository) UndoArchive(ctx context.Context, id primitive.ObjectID, isActive bool) error {
+	filter := bson.M{"_id": id, "archived_at": bson.M{"$exists": true}}
+	update := bson.M{
+		"$unset": bson.M{"archived_at": ""},
+		"$set":   bson.M{"is_active": isActive, "updated_at": r.now()},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// Restore brings an archived court back. It stays inactive until an admin opens it.
+// It returns false if the court does not exist or is not archived.
+func (r *CourtRepository) Restore(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := bson.M{"_id": id, "archived_at": bson.M{"$exists": true}}
+	update := bson.M{
+		"$unset": bson.M{"archived_at": ""},
+		"$set":   bson.M{"updated_at": r.now()},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
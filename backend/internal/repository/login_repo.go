@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// Login throttle kinds
+const (
+	LoginThrottleAccount = "account"
+	LoginThrottleIP      = "ip"
+)
+
+const (
+	// loginThrottleRetention is how long failures are remembered after the last one
+	loginThrottleRetention = 24 * time.Hour
+	// loginAttemptRetention is how long the login audit trail is kept
+	loginAttemptRetention = 90 * 24 * time.Hour
+)
+
+// LoginLimits configures how failed logins against one key are slowed down and locked out
+type LoginLimits struct {
+	FreeAttempts int           // failures allowed before delays start
+	MaxDelay     time.Duration // longest delay between two attempts
+	LockAfter    int           // failures that lock the key
+	LockDuration time.Duration // first lockout, doubled for every further lockout
+	MaxLock      time.Duration // longest lockout
+}
+
+// Default limits. An IP address gets more room than an account because
+// students often share the campus network.
+var (
+	AccountLoginLimits = LoginLimits{
+		FreeAttempts: 3,
+		MaxDelay:     30 * time.Second,
+		LockAfter:    10,
+		LockDuration: 15 * time.Minute,
+		MaxLock:      24 * time.Hour,
+	}
+	IPLoginLimits = LoginLimits{
+		FreeAttempts: 20,
+		MaxDelay:     10 * time.Second,
+		LockAfter:    100,
+		LockDuration: 15 * time.Minute,
+		MaxLock:      24 * time.Hour,
+	}
+)
+
+// Delay returns how long to wait before the next attempt after the given number of failures
+func (l LoginLimits) Delay(failures int) time.Duration {
+	if failures <= l.FreeAttempts {
+		return 0
+	}
+
+	delay := time.Second
+	for i := l.FreeAttempts + 1; i < failures && delay < l.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > l.MaxDelay {
+		delay = l.MaxDelay
+	}
+	return delay
+}
+
+// Lock returns how long a key is locked after it has already been locked lockouts times
+func (l LoginLimits) Lock(lockouts int) time.Duration {
+	lock := l.LockDuration
+	for i := 0; i < lockouts && lock < l.MaxLock; i++ {
+		lock *= 2
+	}
+	if lock > l.MaxLock {
+		lock = l.MaxLock
+	}
+	return lock
+}
+
+// LoginRetryAt returns when the throttled key may try again, and false if it may try now
+func LoginRetryAt(throttle *models.LoginThrottle, now time.Time) (time.Time, bool) {
+	var retryAt time.Time
+	if throttle.LockedUntil != nil && throttle.LockedUntil.After(retryAt) {
+		retryAt = *throttle.LockedUntil
+	}
+	if throttle.NextAttemptAt != nil && throttle.NextAttemptAt.After(retryAt) {
+		retryAt = *throttle.NextAttemptAt
+	}
+	return retryAt, retryAt.After(now)
+}
+
+// LoginRepository handles all database operations related to login throttling
+// and the login audit trail
+type LoginRepository struct {
+	clocked
+	throttles *mongo.Collection
+	attempts  *mongo.Collection
+}
+
+// NewLoginRepository creates a new login repository
+func NewLoginRepository(db *mongo.Database) *LoginRepository {
+	return &LoginRepository{
+		throttles: db.Collection("login_throttles"),
+		attempts:  db.Collection("login_attempts"),
+	}
+}
+
+// EnsureIndexes creates the indexes the login repository relies on
+func (r *LoginRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.throttles.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "kind", Value: 1}, {Key: "subject", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// ประวัติการ login เก็บไว้ 90 วัน
+	_, err = r.attempts.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(loginAttemptRetention / time.Second))},
+		{Keys: bson.D{{Key: "student_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "ip_address", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+// FindThrottle finds the throttle of an account or IP address. It returns nil if the key
+// has no recent failures.
+func (r *LoginRepository) FindThrottle(ctx context.Context, kind string, subject string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+
+	filter := bson.M{"kind": kind, "subject": subject}
+	err := r.throttles.FindOne(ctx, filter).Decode(&throttle)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &throttle, nil
+}
+
+// RecordFailure counts a failed login against a key and applies the delay or lockout it has earned
+func (r *LoginRepository) RecordFailure(ctx context.Context, kind string, subject string, limits LoginLimits) (*models.LoginThrottle, error) {
+	now := r.now()
+
+	filter := bson.M{"kind": kind, "subject": subject}
+	update := bson.M{
+		"$inc":         bson.M{"failures": 1},
+		"$set":         bson.M{"last_failure_at": now, "expires_at": now.Add(loginThrottleRetention)},
+		"$setOnInsert": bson.M{"lockouts": 0, "created_at": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var throttle models.LoginThrottle
+	if err := r.throttles.FindOneAndUpdate(ctx, filter, update, opts).Decode(&throttle); err != nil {
+		return nil, err
+	}
+
+	if throttle.Failures >= limits.LockAfter {
+		// ล็อกแล้วเริ่มนับใหม่ ถ้ายังผิดต่อจะถูกล็อกนานขึ้นเป็นเท่าตัว
+		lockedUntil := now.Add(limits.Lock(throttle.Lockouts))
+		throttle.LockedUntil = &lockedUntil
+		throttle.NextAttemptAt = nil
+		throttle.Failures = 0
+		throttle.Lockouts++
+		throttle.ExpiresAt = lockedUntil.Add(loginThrottleRetention)
+		update = bson.M{
+			"$set": bson.M{
+				"locked_until": lockedUntil,
+				"failures":     0,
+				"expires_at":   throttle.ExpiresAt,
+			},
+			"$inc":   bson.M{"lockouts": 1},
+			"$unset": bson.M{"next_attempt_at": ""},
+		}
+	} else if delay := limits.Delay(throttle.Failures); delay > 0 {
+		nextAttemptAt := now.Add(delay)
+		throttle.NextAttemptAt = &nextAttemptAt
+		update = bson.M{"$set": bson.M{"next_attempt_at": nextAttemptAt}}
+	} else {
+		return &throttle, nil
+	}
+
+	if _, err := r.throttles.UpdateOne(ctx, bson.M{"_id": throttle.ID}, update); err != nil {
+		return nil, err
+	}
+
+	return &throttle, nil
+}
+
+// ClearThrottle forgets the failures of a key, e.g. after a successful login
+func (r *LoginRepository) ClearThrottle(ctx context.Context, kind string, subject string) error {
+	_, err := r.throttles.DeleteOne(ctx, bson.M{"kind": kind, "subject": subject})
+	return err
+}
+
+// FindThrottles returns throttled keys, most recent failure first
+func (r *LoginRepository) FindThrottles(ctx context.Context, kind string, lockedOnly bool, limit int) ([]*models.LoginThrottle, error) {
+	filter := bson.M{}
+	if kind != "" {
+		filter["kind"] = kind
+	}
+	if lockedOnly {
+		filter["locked_until"] = bson.M{"$gt": r.now()}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "last_failure_at", Value: -1}}).SetLimit(int64(limit))
+
+	cursor, err := r.throttles.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	throttles := []*models.LoginThrottle{}
+	if err := cursor.All(ctx, &throttles); err != nil {
+		return nil, err
+	}
+
+	return throttles, nil
+}
+
+// DeleteThrottle clears a lockout by ID. It returns false if there was nothing to clear.
+func (r *LoginRepository) DeleteThrottle(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.throttles.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// RecordAttempt adds a login attempt to the audit trail
+func (r *LoginRepository) RecordAttempt(ctx context.Context, attempt *models.LoginAttempt) error {
+	attempt.ID = primitive.NewObjectID()
+	attempt.CreatedAt = r.now()
+
+	_, err := r.attempts.InsertOne(ctx, attempt)
+	return err
+}
+
+// FindAttempts returns the login audit trail, newest first
+func (r *LoginRepository) FindAttempts(ctx context.Context, studentID string, ipAddress string, success *bool, limit int) ([]*models.LoginAttempt, error) {
+	filter := bson.M{}
+	if studentID != "" {
+		filter["student_id"] = studentID
+	}
+	if ipAddress != "" {
+		filter["ip_address"] = ipAddress
+	}
+	if success != nil {
+		filter["success"] = *success
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(limit))
+
+	cursor, err := r.attempts.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	attempts := []*models.LoginAttempt{}
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
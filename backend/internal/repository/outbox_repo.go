@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+const (
+	// outboxMaxAttempts is how many times a message is tried before it is dead-lettered
+	outboxMaxAttempts = 8
+	// outboxBaseBackoff is the wait after the first failure; it doubles on every retry
+	outboxBaseBackoff = 30 * time.Second
+	// outboxMaxBackoff caps the wait between retries
+	outboxMaxBackoff = time.Hour
+	// outboxLease is how long a worker may hold a message before another worker retries it
+	outboxLease = 2 * time.Minute
+)
+
+// NewOutboxMessage creates a pending outbox message about a booking.
+// The repository that writes it stamps the times with its clock.
+func NewOutboxMessage(template string, booking *models.Booking) *models.OutboxMessage {
+	return &models.OutboxMessage{
+		ID:          primitive.NewObjectID(),
+		Template:    template,
+		BookingID:   booking.ID,
+		StudentID:   booking.StudentID,
+		Status:      "pending",
+		MaxAttempts: outboxMaxAttempts,
+		History:     []models.DeliveryAttempt{},
+	}
+}
+
+// NewAccountOutboxMessage creates a pending outbox message about a user's account,
+// such as an email verification link. data holds template values like the link.
+func NewAccountOutboxMessage(template string, user *models.User, data map[string]string) *models.OutboxMessage {
+	return &models.OutboxMessage{
+		ID:          primitive.NewObjectID(),
+		Template:    template,
+		StudentID:   user.StudentID,
+		Data:        data,
+		Status:      "pending",
+		MaxAttempts: outboxMaxAttempts,
+		History:     []models.DeliveryAttempt{},
+	}
+}
+
+// stampOutboxMessage sets the creation time of a new message and makes it due immediately
+func stampOutboxMessage(msg *models.OutboxMessage, now time.Time) {
+	msg.NextAttemptAt = now
+	msg.CreatedAt = now
+	msg.UpdatedAt = now
+}
+
+// OutboxBackoff returns how long to wait before retrying after the given number of attempts
+func OutboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	return backoff
+}
+
+// OutboxRepository handles all database operations related to the notification outbox
+type OutboxRepository struct {
+	clocked
+	collection *mongo.Collection
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *mongo.Database) *OutboxRepository {
+	return &OutboxRepository{
+		collection: db.Collection("outbox"),
+	}
+}
+
+// Create queues a message that is not tied to a booking change
+func (r *OutboxRepository) Create(ctx context.Context, msg *models.OutboxMessage) error {
+	stampOutboxMessage(msg, r.now())
+	_, err := r.collection.InsertOne(ctx, msg)
+	return err
+}
+
+// ClaimDue takes the next message that is due for delivery, or returns nil if there is none.
+// Messages left in "sending" by a crashed worker are retried once their lease expires.
+func (r *OutboxRepository) ClaimDue(ctx context.Context) (*models.OutboxMessage, error) {
+	now := r.now()
+	filter := bson.M{"$or": []bson.M{
+		{"status": "pending", "next_attempt_at": bson.M{"$lte": now}},
+		{"status": "sending", "locked_until": bson.M{"$lt": now}},
+	}}
+	update := bson.M{"$set": bson.M{
+		"status":       "sending",
+		"locked_until": now.Add(outboxLease),
+		"updated_at":   now,
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var msg models.OutboxMessage
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&msg)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+// MarkSent records a successful delivery
+func (r *OutboxRepository) MarkSent(ctx context.Context, id primitive.ObjectID) error {
+	now := r.now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":     "sent",
+			"sent_at":    now,
+			"last_error": "",
+			"updated_at": now,
+		},
+		"$unset": bson.M{"data": ""}, // ลิงก์ที่มี token ไม่ต้องเก็บไว้อีก
+		"$inc":   bson.M{"attempts": 1},
+		"$push":  bson.M{"history": models.DeliveryAttempt{At: now}},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// MarkFailed records a failed delivery and schedules a retry with exponential backoff,
+// or moves the message to the dead state once it has used all of its attempts.
+// Permanent failures are dead-lettered immediately.
+func (r *OutboxRepository) MarkFailed(ctx context.Context, msg *models.OutboxMessage, deliveryErr error, permanent bool) error {
+	now := r.now()
+	attempts := msg.Attempts + 1
+
+	set := bson.M{
+		"attempts":   attempts,
+		"last_error": deliveryErr.Error(),
+		"updated_at": now,
+	}
+	if permanent || attempts >= msg.MaxAttempts {
+		set["status"] = "dead"
+	} else {
+		set["status"] = "pending"
+		set["next_attempt_at"] = now.Add(OutboxBackoff(attempts))
+	}
+
+	update := bson.M{
+		"$set":  set,
+		"$push": bson.M{"history": models.DeliveryAttempt{At: now, Error: deliveryErr.Error()}},
+	}
+	if set["status"] == "dead" {
+		update["$unset"] = bson.M{"data": ""}
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": msg.ID}, update)
+	return err
+}
+
+// FindAll finds outbox messages, newest first, optionally filtered by student, booking and status
+func (r *OutboxRepository) FindAll(ctx context.Context, studentID string, bookingID *primitive.ObjectID, status string, limit int) ([]*models.OutboxMessage, error) {
+	filter := bson.M{}
+	if studentID != "" {
+		filter["student_id"] = studentID
+	}
+	if bookingID != nil {
+		filter["booking_id"] = *bookingID
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	messages := []*models.OutboxMessage{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// Retry puts a dead message back in the queue with a fresh set of attempts
+func (r *OutboxRepository) Retry(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	update := bson.M{"$set": bson.M{
+		"status":          "pending",
+		"attempts":        0,
+		"next_attempt_at": r.now(),
+		"updated_at":      r.now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": "dead"}, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// SeriesRepository handles all database operations related to recurring booking series
+type SeriesRepository struct {
+	clocked
+	collection *mongo.Collection
+}
+
+// NewSeriesRepository creates a new series repository
+func NewSeriesRepository(db *mongo.Database) *SeriesRepository {
+	return &SeriesRepository{
+		collection: db.Collection("booking_series"),
+	}
+}
+
+// Create creates a new booking series
+func (r *SeriesRepository) Create(ctx context.Context, series *models.BookingSeries) error {
+	series.CreatedAt = r.now()
+	series.UpdatedAt = r.now()
+	series.Status = "active"
+
+	_, err := r.collection.InsertOne(ctx, series)
+	return err
+}
+
+// FindByID finds a booking series by ID
+func (r *SeriesRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.BookingSeries, error) {
+	var series models.BookingSeries
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&series)
+	if err != nil {
+		return nil, err
+	}
+
+	return &series, nil
+}
+
+// UpdateStatus updates the status of a booking series
+func (r *SeriesRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	update := bson.M{"$set": bson.M{
+		"status":     status,
+		"updated_at": r.now(),
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// Delete deletes a booking series
+func (r *SeriesRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
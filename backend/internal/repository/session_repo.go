@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// SessionRepository handles all database operations related to login sessions
+// and individually revoked access tokens
+type SessionRepository struct {
+	clocked
+	collection *mongo.Collection
+	revoked    *mongo.Collection
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *mongo.Database) *SessionRepository {
+	return &SessionRepository{
+		collection: db.Collection("sessions"),
+		revoked:    db.Collection("revoked_tokens"),
+	}
+}
+
+// EnsureIndexes creates the indexes the session repository relies on
+func (r *SessionRepository) EnsureIndexes(ctx context.Context) error {
+	// ลบ session และ token ที่หมดอายุแล้วอัตโนมัติ
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.revoked.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Create stores a new session
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	session.CreatedAt = r.now()
+	session.LastUsedAt = session.CreatedAt
+
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+// FindByID finds a session by ID
+func (r *SessionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	var session models.Session
+
+	filter := bson.M{"_id": id}
+	err := r.collection.FindOne(ctx, filter).Decode(&session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// IsActive reports whether a session exists, has not expired and has not been revoked
+func (r *SessionRepository) IsActive(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id":        id,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": r.now()},
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Rotate replaces a session's refresh token hash, but only if the presented token is still
+// the current one. It returns false if the token was already rotated or the session was revoked.
+func (r *SessionRepository) Rotate(ctx context.Context, id primitive.ObjectID, oldHash string, newHash string, expiresAt time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":                id,
+		"refresh_token_hash": oldHash,
+		"revoked_at":         bson.M{"$exists": false},
+		"expires_at":         bson.M{"$gt": r.now()},
+	}
+	update := bson.M{"$set": bson.M{
+		"refresh_token_hash": newHash,
+		"last_used_at":       r.now(),
+		"expires_at":         expiresAt,
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// Revoke ends a session
+func (r *SessionRepository) Revoke(ctx context.Context, id primitive.ObjectID, reason string) error {
+	filter := bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{
+		"revoked_at":     r.now(),
+		"revoked_reason": reason,
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// RevokeAllForUser ends every session of a user and returns how many were active
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, reason string) (int64, error) {
+	filter := bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{
+		"revoked_at":     r.now(),
+		"revoked_reason": reason,
+	}}
+
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// RevokeToken revokes a single access token by its jti until it would have expired anyway
+func (r *SessionRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	filter := bson.M{"_id": jti}
+	update := bson.M{"$set": bson.M{"expires_at": expiresAt}}
+
+	_, err := r.revoked.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// IsTokenRevoked reports whether an access token was revoked by its jti
+func (r *SessionRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	count, err := r.revoked.CountDocuments(ctx, bson.M{"_id": jti}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// IDs of the settings documents
+const (
+	operatingHoursID = "operating_hours" // เวลาทำการเดิมก่อนมีหลายสนาม ใช้กับสนามที่ยังไม่ได้ตั้งค่า
+	bookingPolicyID  = "booking_policy"
+	noShowRolloutID  = "no_show_rollout" // เวลาที่เริ่มตรวจการไม่มาใช้คอร์ท
+)
+
+// venueHoursID returns the ID of a venue's operating hours document
+func venueHoursID(venueID primitive.ObjectID) string {
+	return operatingHoursID + ":" + venueID.Hex()
+}
+
+// SettingsRepository handles all database operations related to venue settings
+type SettingsRepository struct {
+	clocked
+	collection *mongo.Collection
+}
+
+// NewSettingsRepository creates a new settings repository
+func NewSettingsRepository(db *mongo.Database) *SettingsRepository {
+	return &SettingsRepository{
+		collection: db.Collection("settings"),
+	}
+}
+
+// DefaultOperatingHours returns the hours used until an admin configures them:
+// open every day 08:00-22:00 with 30-minute slots
+func DefaultOperatingHours() *models.OperatingHours {
+	hours := &models.OperatingHours{
+		ID:                 operatingHoursID,
+		SlotMinutes:        30,
+		MinDurationMinutes: 30,
+	}
+	for weekday := 0; weekday < 7; weekday++ {
+		hours.Days = append(hours.Days, models.DayHours{
+			Weekday:   weekday,
+			OpenTime:  "08:00",
+			CloseTime: "22:00",
+		})
+	}
+	return hours
+}
+
+// GetOperatingHours returns the operating hours configured for a venue. A venue without
+// its own hours uses the hours stored before venues existed, or the defaults if none are stored.
+func (r *SettingsRepository) GetOperatingHours(ctx context.Context, venueID primitive.ObjectID) (*models.OperatingHours, error) {
+	for _, id := range []string{venueHoursID(venueID), operatingHoursID} {
+		var hours models.OperatingHours
+
+		err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&hours)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &hours, nil
+	}
+
+	return DefaultOperatingHours(), nil
+}
+
+// UpdateOperatingHours replaces the stored operating hours of a venue
+func (r *SettingsRepository) UpdateOperatingHours(ctx context.Context, venueID primitive.ObjectID, hours *models.OperatingHours) error {
+	hours.ID = venueHoursID(venueID)
+	hours.UpdatedAt = r.now()
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": hours.ID}, hours, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DefaultBookingPolicy returns the fair-use limits used until an admin configures them
+func DefaultBookingPolicy() *models.BookingPolicy {
+	return &models.BookingPolicy{
+		ID:                bookingPolicyID,
+		MaxActiveBookings: 3,
+		MaxHoursPerDay:    2,
+		MaxHoursPerWeek:   6,
+		MaxDaysAhead:      14,
+		NoBackToBack:      true,
+
+		CheckInOpensMinutes: 15,
+		CheckInGraceMinutes: 15,
+		NoShowLimit:         3,
+		NoShowWindowDays:    30,
+		NoShowBanDays:       7,
+
+		UnverifiedMaxActiveBookings: 1,
+
+		CancelCutoffHours: 2,
+	}
+}
+
+// GetBookingPolicy returns the configured booking policy, or the defaults if none is stored
+func (r *SettingsRepository) GetBookingPolicy(ctx context.Context) (*models.BookingPolicy, error) {
+	var policy models.BookingPolicy
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": bookingPolicyID}).Decode(&policy)
+	if err == mongo.ErrNoDocuments {
+		return DefaultBookingPolicy(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// UpdateBookingPolicy replaces the stored booking policy
+func (r *SettingsRepository) UpdateBookingPolicy(ctx context.Context, policy *models.BookingPolicy) error {
+	policy.ID = bookingPolicyID
+	policy.UpdatedAt = r.now()
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": bookingPolicyID}, policy, options.Replace().SetUpsert(true))
+	return err
+}
+
+// NoShowRollout returns when no-show processing was first run. The time is stored on the
+// first call, so bookings that started before no-shows existed are never marked as no-shows.
+func (r *SettingsRepository) NoShowRollout(ctx context.Context) (time.Time, error) {
+	var doc struct {
+		StartedAt time.Time `bson:"started_at"`
+	}
+
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": noShowRolloutID},
+		bson.M{"$setOnInsert": bson.M{"started_at": r.now()}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return doc.StartedAt, nil
+}
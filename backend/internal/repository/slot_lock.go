@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// slotLockTTL is how long a lock is honoured before another request may take it over.
+	// It only matters if the holder crashes before releasing the lock.
+	slotLockTTL = 10 * time.Second
+	// slotLockWait is how long a request waits for a busy lock before giving up
+	slotLockWait = 5 * time.Second
+	// slotLockRetention is how long a released lock document is kept before MongoDB removes it
+	slotLockRetention = 24 * time.Hour
+)
+
+// ErrSlotLockTimeout is returned when the court/day lock could not be acquired in time
+var ErrSlotLockTimeout = errors.New("timed out waiting for booking slot lock")
+
+// EnsureIndexes creates the indexes the booking repository relies on
+func (r *BookingRepository) EnsureIndexes(ctx context.Context) error {
+	// มี lock ได้แค่หนึ่งเอกสารต่อคอร์ทต่อวัน และลบเอกสารของวันที่ไม่มีใครจองแล้ว
+	_, err := r.locks.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "court_id", Value: 1}, {Key: "day", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(slotLockRetention.Seconds())),
+		},
+	})
+	return err
+}
+
+// lockCourtDay acquires the lock document for a court on a given day.
+// The returned function releases the lock and must always be called. Releasing expires the
+// document instead of deleting it, so there is only ever one lock document per court and day.
+func (r *BookingRepository) lockCourtDay(ctx context.Context, courtID primitive.ObjectID, day time.Time) (func(), error) {
+	owner := primitive.NewObjectID()
+	// day คือเที่ยงคืนตามเวลาของสนาม ใช้เวลา UTC ของจุดนั้นเป็น key
+	// เพื่อให้วันที่เพิ่งแปลงจาก request และวันที่อ่านจากฐานข้อมูลได้ key เดียวกัน
+	key := day.UTC().Format(time.RFC3339)
+	// การรอและอายุของ lock ใช้เวลาจริงของเครื่อง ไม่ใช่ clock ของ repository
+	// เพราะ lock ต้องหมดอายุจริงเมื่อผู้ถือ lock ค้าง แม้ตอนทดสอบที่ตรึงเวลาไว้
+	deadline := time.Now().Add(slotLockWait)
+
+	for {
+		now := time.Now()
+
+		// ยึด lock ได้เมื่อยังไม่มีเอกสาร หรือ lock เดิมหมดอายุแล้ว
+		// ถ้ามีคนถือ lock อยู่ filter จะไม่ match และ upsert จะชน unique index
+		filter := bson.M{
+			"court_id":   courtID,
+			"day":        key,
+			"expires_at": bson.M{"$lt": now},
+		}
+		update := bson.M{"$set": bson.M{
+			"owner":      owner,
+			"expires_at": now.Add(slotLockTTL),
+		}}
+
+		_, err := r.locks.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if err == nil {
+			return func() {
+				// ใช้ context แยกเพื่อให้ปล่อย lock ได้แม้ request ถูกยกเลิกไปแล้ว
+				releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+				defer cancel()
+				_, _ = r.locks.UpdateOne(releaseCtx,
+					bson.M{"court_id": courtID, "day": key, "owner": owner},
+					bson.M{"$set": bson.M{"expires_at": time.Now()}},
+				)
+			}, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrSlotLockTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(10+rand.Intn(40)) * time.Millisecond):
+		}
+	}
+}
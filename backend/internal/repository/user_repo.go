@@ -7,12 +7,17 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"courtopia-reserve/backend/internal/models"
 )
 
+// emailVerificationMigrationID marks in the settings collection that GrandfatherEmailVerification has run
+const emailVerificationMigrationID = "migration:email_verified"
+
 // UserRepository handles all database operations related to users
 type UserRepository struct {
+	clocked
 	collection *mongo.Collection
 }
 
@@ -38,8 +43,8 @@ func (r *UserRepository) FindByStudentID(ctx context.Context, studentID string)
 
 // Create creates a new user
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
-	user.CreatedAt = time.Now()
-	user.UpdatedAt = time.Now()
+	user.CreatedAt = r.now()
+	user.UpdatedAt = r.now()
 
 	_, err := r.collection.InsertOne(ctx, user)
 	return err
@@ -58,9 +63,33 @@ func (r *UserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*
 	return &user, nil
 }
 
+// FindByIDs finds users by their IDs and returns them keyed by ID
+func (r *UserRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.User, error) {
+	users := make(map[primitive.ObjectID]*models.User, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return nil, err
+		}
+		users[user.ID] = &user
+	}
+
+	return users, cursor.Err()
+}
+
 // Update updates an existing user
 func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
-	user.UpdatedAt = time.Now()
+	user.UpdatedAt = r.now()
 
 	filter := bson.M{"_id": user.ID}
 	update := bson.M{"$set": user}
@@ -69,6 +98,167 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	return err
 }
 
+// SetBookingBan prevents a user from booking until the given time
+func (r *UserRepository) SetBookingBan(ctx context.Context, id primitive.ObjectID, until time.Time) error {
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{
+		"booking_banned_until": until,
+		"updated_at":           r.now(),
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// FindByCalendarToken finds the user who owns a calendar feed token
+func (r *UserRepository) FindByCalendarToken(ctx context.Context, token string) (*models.User, error) {
+	var user models.User
+
+	filter := bson.M{"calendar_token": token}
+	err := r.collection.FindOne(ctx, filter).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// SetCalendarToken replaces a user's calendar feed token, which invalidates the old feed URL
+func (r *UserRepository) SetCalendarToken(ctx context.Context, id primitive.ObjectID, token string) error {
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{
+		"calendar_token": token,
+		"updated_at":     r.now(),
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// FindByEmail finds a user by email address
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+
+	filter := bson.M{"email": email}
+	err := r.collection.FindOne(ctx, filter).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// MarkEmailVerified records that a user owns their email address. Nothing changes if the
+// user has switched to a different address since the verification email was sent.
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID, email string) (bool, error) {
+	filter := bson.M{"_id": id, "email": email}
+	update := bson.M{"$set": bson.M{
+		"email_verified_at": r.now(),
+		"updated_at":        r.now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// UpdatePassword replaces a user's password hash
+func (r *UserRepository) UpdatePassword(ctx context.Context, id primitive.ObjectID, hashedPassword string) error {
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{
+		"password":   hashedPassword,
+		"updated_at": r.now(),
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// FindAll returns users sorted by student ID, optionally only those with the given role
+func (r *UserRepository) FindAll(ctx context.Context, role string, limit int) ([]*models.User, error) {
+	filter := bson.M{}
+	if role != "" {
+		filter["role"] = role
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "student_id", Value: 1}}).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []*models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// CountByRole counts the users with a role
+func (r *UserRepository) CountByRole(ctx context.Context, role string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"role": role})
+}
+
+// UpdateRole changes a user's role and the venues it applies to (none means every venue)
+func (r *UserRepository) UpdateRole(ctx context.Context, id primitive.ObjectID, role string, venueIDs []primitive.ObjectID) error {
+	filter := bson.M{"_id": id}
+	set := bson.M{
+		"role":       role,
+		"updated_at": r.now(),
+	}
+	update := bson.M{"$set": set}
+	if len(venueIDs) > 0 {
+		set["venue_ids"] = venueIDs
+	} else {
+		update["$unset"] = bson.M{"venue_ids": ""}
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// RenameRole moves every user with the old role name to the new one
+func (r *UserRepository) RenameRole(ctx context.Context, from string, to string) (int64, error) {
+	update := bson.M{"$set": bson.M{"role": to}}
+
+	result, err := r.collection.UpdateMany(ctx, bson.M{"role": from}, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// GrandfatherEmailVerification marks every user that has not verified an email address as
+// verified, so accounts created before email verification keep their booking limits.
+// It runs once; later starts see the marker in the settings collection and do nothing.
+func (r *UserRepository) GrandfatherEmailVerification(ctx context.Context) (int64, error) {
+	settings := r.collection.Database().Collection("settings")
+
+	err := settings.FindOne(ctx, bson.M{"_id": emailVerificationMigrationID}).Err()
+	if err == nil {
+		return 0, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return 0, err
+	}
+
+	now := r.now()
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"email_verified_at": nil},
+		bson.M{"$set": bson.M{"email_verified_at": now, "updated_at": now}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = settings.InsertOne(ctx, bson.M{"_id": emailVerificationMigrationID, "applied_at": now})
+	return result.ModifiedCount, err
+}
+
 // Delete deletes a user
 func (r *UserRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	filter := bson.M{"_id": id}
@@ -80,4 +270,3 @@ func (r *UserRepository) UpdateOne(ctx context.Context, filter interface{}, upda
 	_, err := r.collection.UpdateOne(ctx, filter, update)
 	return err
 }
-
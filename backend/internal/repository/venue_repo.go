@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// DefaultVenueName is the name of the venue created for data that predates venues
+const DefaultVenueName = "Main Hall"
+
+// venueCollections are the collections whose documents belong to a venue
+var venueCollections = []string{"courts", "bookings", "booking_series", "blackouts", "waitlist"}
+
+// localTimeFields are the date fields that used to hold the venue's wall-clock time stored as if it were UTC
+var localTimeFields = map[string][]string{
+	"bookings":       {"booking_date", "start_time", "end_time"},
+	"booking_series": {"start_date", "until"},
+	"blackouts":      {"start_time", "end_time"},
+	"waitlist":       {"booking_date", "start_time", "end_time"},
+}
+
+// localTimesMigrationID marks in the settings collection that MigrateLocalTimes has run
+const localTimesMigrationID = "migration:local_times"
+
+// localTimesMigratedField marks a document whose times MigrateLocalTimes has already converted
+const localTimesMigratedField = "tz_migrated"
+
+// VenueRepository handles all database operations related to venues
+type VenueRepository struct {
+	clocked
+	collection *mongo.Collection
+}
+
+// NewVenueRepository creates a new venue repository
+func NewVenueRepository(db *mongo.Database) *VenueRepository {
+	return &VenueRepository{
+		collection: db.Collection("venues"),
+	}
+}
+
+// inVenues limits a filter to documents of the given venues. No venues means every venue.
+func inVenues(filter bson.M, venueIDs []primitive.ObjectID) bson.M {
+	if len(venueIDs) > 0 {
+		filter["venue_id"] = bson.M{"$in": venueIDs}
+	}
+	return filter
+}
+
+// Create inserts a new venue
+func (r *VenueRepository) Create(ctx context.Context, venue *models.Venue) error {
+	venue.CreatedAt = r.now()
+	venue.UpdatedAt = venue.CreatedAt
+
+	result, err := r.collection.InsertOne(ctx, venue)
+	if err != nil {
+		return err
+	}
+	venue.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindAll finds all venues, optionally limited to the given IDs
+func (r *VenueRepository) FindAll(ctx context.Context, ids []primitive.ObjectID) ([]*models.Venue, error) {
+	filter := bson.M{}
+	if len(ids) > 0 {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	venues := []*models.Venue{}
+	if err := cursor.All(ctx, &venues); err != nil {
+		return nil, err
+	}
+
+	return venues, nil
+}
+
+// FindByID finds a venue by ID
+func (r *VenueRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Venue, error) {
+	var venue models.Venue
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&venue)
+	if err != nil {
+		return nil, err
+	}
+
+	return &venue, nil
+}
+
+// Update saves the editable details of a venue
+func (r *VenueRepository) Update(ctx context.Context, venue *models.Venue) error {
+	venue.UpdatedAt = r.now()
+
+	update := bson.M{"$set": bson.M{
+		"name":       venue.Name,
+		"address":    venue.Address,
+		"timezone":   venue.Timezone,
+		"updated_at": venue.UpdatedAt,
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": venue.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// EnsureDefault creates the default venue in the given timezone when there are no venues
+// yet and moves courts, bookings, series, blackouts and waitlist entries created before
+// venues existed into it. It is safe to run on every start.
+func (r *VenueRepository) EnsureDefault(ctx context.Context, timezone string) error {
+	var venue models.Venue
+
+	err := r.collection.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}})).Decode(&venue)
+	if err == mongo.ErrNoDocuments {
+		venue = models.Venue{Name: DefaultVenueName, Timezone: timezone}
+		err = r.Create(ctx, &venue)
+	}
+	if err != nil {
+		return err
+	}
+
+	db := r.collection.Database()
+	for _, name := range venueCollections {
+		_, err := db.Collection(name).UpdateMany(ctx,
+			bson.M{"venue_id": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"venue_id": venue.ID}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MigrateLocalTimes converts booking, series, blackout and waitlist times that were stored
+// as the venue's wall-clock time labelled UTC into the real instant in the venue's timezone.
+// It runs once; later starts see the marker in the settings collection and do nothing.
+// Each converted document is marked as well, so a run that stopped part way can be
+// repeated without shifting the documents it already converted a second time.
+func (r *VenueRepository) MigrateLocalTimes(ctx context.Context) error {
+	db := r.collection.Database()
+	settings := db.Collection("settings")
+
+	err := settings.FindOne(ctx, bson.M{"_id": localTimesMigrationID}).Err()
+	if err == nil {
+		return nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	venues, err := r.FindAll(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, venue := range venues {
+		for name, fields := range localTimeFields {
+			set := bson.M{localTimesMigratedField: true}
+			for _, field := range fields {
+				set[field] = wallClockIn("$"+field, venue.Timezone)
+			}
+			_, err := db.Collection(name).UpdateMany(ctx,
+				bson.M{"venue_id": venue.ID, localTimesMigratedField: bson.M{"$ne": true}},
+				mongo.Pipeline{{{Key: "$set", Value: set}}},
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = settings.InsertOne(ctx, bson.M{"_id": localTimesMigrationID, "applied_at": r.now()})
+	return err
+}
+
+// wallClockIn reads the UTC date and time of a date field as wall-clock time in timezone.
+// Missing fields stay missing.
+func wallClockIn(field, timezone string) bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$type": field}, "date"}},
+		bson.M{"$dateFromParts": bson.M{
+			"year":        bson.M{"$year": field},
+			"month":       bson.M{"$month": field},
+			"day":         bson.M{"$dayOfMonth": field},
+			"hour":        bson.M{"$hour": field},
+			"minute":      bson.M{"$minute": field},
+			"second":      bson.M{"$second": field},
+			"millisecond": bson.M{"$millisecond": field},
+			"timezone":    timezone,
+		}},
+		field,
+	}}
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// WaitlistRepository handles all database operations related to the booking waitlist
+type WaitlistRepository struct {
+	clocked
+	collection *mongo.Collection
+}
+
+// NewWaitlistRepository creates a new waitlist repository
+func NewWaitlistRepository(db *mongo.Database) *WaitlistRepository {
+	return &WaitlistRepository{
+		collection: db.Collection("waitlist"),
+	}
+}
+
+// Create adds a user to the waitlist
+func (r *WaitlistRepository) Create(ctx context.Context, entry *models.WaitlistEntry) error {
+	entry.CreatedAt = r.now()
+	entry.UpdatedAt = r.now()
+	entry.Status = "waiting"
+
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+// FindByID finds a waitlist entry by ID
+func (r *WaitlistRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// FindByStudentID finds a user's waitlist entries for windows that have not started yet
+func (r *WaitlistRepository) FindByStudentID(ctx context.Context, studentID string) ([]*models.WaitlistEntry, error) {
+	filter := bson.M{
+		"student_id": studentID,
+		"start_time": bson.M{"$gt": r.now()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []*models.WaitlistEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// ExistsWaiting checks if the user is already waiting for the same court and time
+func (r *WaitlistRepository) ExistsWaiting(ctx context.Context, studentID string, venueID primitive.ObjectID, courtNumber int, startTime time.Time, endTime time.Time) (bool, error) {
+	filter := bson.M{
+		"student_id":   studentID,
+		"venue_id":     venueID,
+		"court_number": courtNumber,
+		"start_time":   startTime,
+		"end_time":     endTime,
+		"status":       "waiting",
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// FindWaitingForSlot finds waiting entries, oldest first, that fit inside a freed slot on a court.
+// Entries for any court of the venue (court number 0) also match.
+func (r *WaitlistRepository) FindWaitingForSlot(ctx context.Context, venueID primitive.ObjectID, courtNumber int, startTime time.Time, endTime time.Time) ([]*models.WaitlistEntry, error) {
+	filter := bson.M{
+		"status":       "waiting",
+		"venue_id":     venueID,
+		"court_number": bson.M{"$in": []int{courtNumber, 0}},
+		"start_time":   bson.M{"$gte": startTime, "$gt": r.now()},
+		"end_time":     bson.M{"$lte": endTime},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []*models.WaitlistEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Claim marks a waiting entry as being promoted so that only one cancellation can promote it.
+// It returns false if the entry was no longer waiting.
+func (r *WaitlistRepository) Claim(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	return r.transition(ctx, id, "waiting", bson.M{"status": "promoting"})
+}
+
+// Release puts a claimed entry back on the waitlist after a failed promotion
+func (r *WaitlistRepository) Release(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.transition(ctx, id, "promoting", bson.M{"status": "waiting"})
+	return err
+}
+
+// MarkBooked records the booking a claimed entry was promoted to
+func (r *WaitlistRepository) MarkBooked(ctx context.Context, id primitive.ObjectID, bookingID primitive.ObjectID) error {
+	_, err := r.transition(ctx, id, "promoting", bson.M{"status": "booked", "booking_id": bookingID})
+	return err
+}
+
+// transition updates an entry only if it is still in the expected status
+func (r *WaitlistRepository) transition(ctx context.Context, id primitive.ObjectID, from string, set bson.M) (bool, error) {
+	set["updated_at"] = r.now()
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": from}, bson.M{"$set": set})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// Cancel removes a user from the waitlist
+func (r *WaitlistRepository) Cancel(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{"$set": bson.M{
+		"status":     "cancelled",
+		"updated_at": r.now(),
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": "waiting"}, update)
+	return err
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// webhookMaxAttempts is how many times a payload is posted before it is dead-lettered
+const webhookMaxAttempts = 10
+
+// WebhookDeliveryRepository handles all database operations related to webhook deliveries
+type WebhookDeliveryRepository struct {
+	clocked
+	collection *mongo.Collection
+}
+
+// NewWebhookDeliveryRepository creates a new webhook delivery repository
+func NewWebhookDeliveryRepository(db *mongo.Database) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{
+		collection: db.Collection("webhook_deliveries"),
+	}
+}
+
+// Enqueue queues a payload for delivery to a webhook
+func (r *WebhookDeliveryRepository) Enqueue(ctx context.Context, webhookID primitive.ObjectID, event string, payload []byte) (*models.WebhookDelivery, error) {
+	now := r.now()
+	delivery := &models.WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		WebhookID:     webhookID,
+		Event:         event,
+		Payload:       string(payload),
+		Status:        "pending",
+		MaxAttempts:   webhookMaxAttempts,
+		NextAttemptAt: now,
+		History:       []models.WebhookAttempt{},
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	_, err := r.collection.InsertOne(ctx, delivery)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// ClaimDue takes the next delivery that is due, or returns nil if there is none.
+// Deliveries left in "sending" by a crashed worker are retried once their lease expires.
+func (r *WebhookDeliveryRepository) ClaimDue(ctx context.Context) (*models.WebhookDelivery, error) {
+	now := r.now()
+	filter := bson.M{"$or": []bson.M{
+		{"status": "pending", "next_attempt_at": bson.M{"$lte": now}},
+		{"status": "sending", "locked_until": bson.M{"$lt": now}},
+	}}
+	update := bson.M{"$set": bson.M{
+		"status":       "sending",
+		"locked_until": now.Add(outboxLease),
+		"updated_at":   now,
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var delivery models.WebhookDelivery
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// MarkDelivered records a successful delivery
+func (r *WebhookDeliveryRepository) MarkDelivered(ctx context.Context, id primitive.ObjectID, attempt models.WebhookAttempt) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":       "delivered",
+			"delivered_at": attempt.At,
+			"last_error":   "",
+			"updated_at":   r.now(),
+		},
+		"$inc":  bson.M{"attempts": 1},
+		"$push": bson.M{"history": attempt},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// MarkFailed records a failed delivery and schedules a retry with exponential backoff,
+// or moves the delivery to the dead state once it has used all of its attempts.
+// Permanent failures are dead-lettered immediately.
+func (r *WebhookDeliveryRepository) MarkFailed(ctx context.Context, delivery *models.WebhookDelivery, attempt models.WebhookAttempt, permanent bool) error {
+	attempts := delivery.Attempts + 1
+
+	set := bson.M{
+		"attempts":   attempts,
+		"last_error": attempt.Error,
+		"updated_at": r.now(),
+	}
+	if permanent || attempts >= delivery.MaxAttempts {
+		set["status"] = "dead"
+	} else {
+		set["status"] = "pending"
+		set["next_attempt_at"] = r.now().Add(OutboxBackoff(attempts))
+	}
+
+	update := bson.M{
+		"$set":  set,
+		"$push": bson.M{"history": attempt},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": delivery.ID}, update)
+	return err
+}
+
+// FindByWebhookID finds the most recent deliveries to a webhook, optionally filtered by status
+func (r *WebhookDeliveryRepository) FindByWebhookID(ctx context.Context, webhookID primitive.ObjectID, status string, limit int) ([]*models.WebhookDelivery, error) {
+	filter := bson.M{"webhook_id": webhookID}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []*models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// Retry puts a dead delivery back in the queue with a fresh set of attempts
+func (r *WebhookDeliveryRepository) Retry(ctx context.Context, webhookID primitive.ObjectID, id primitive.ObjectID) (bool, error) {
+	update := bson.M{"$set": bson.M{
+		"status":          "pending",
+		"attempts":        0,
+		"next_attempt_at": r.now(),
+		"updated_at":      r.now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "webhook_id": webhookID, "status": "dead"}, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// DeletePending drops deliveries that have not been sent yet, used when a webhook is removed
+func (r *WebhookDeliveryRepository) DeletePending(ctx context.Context, webhookID primitive.ObjectID) error {
+	filter := bson.M{
+		"webhook_id": webhookID,
+		"status":     bson.M{"$in": []string{"pending", "sending"}},
+	}
+	_, err := r.collection.DeleteMany(ctx, filter)
+	return err
+}
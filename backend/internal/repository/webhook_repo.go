@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"courtopia-reserve/backend/internal/models"
+)
+
+// WebhookRepository handles all database operations related to webhook endpoints
+type WebhookRepository struct {
+	clocked
+	collection *mongo.Collection
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *mongo.Database) *WebhookRepository {
+	return &WebhookRepository{
+		collection: db.Collection("webhooks"),
+	}
+}
+
+// Create registers a new webhook
+func (r *WebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	webhook.CreatedAt = r.now()
+	webhook.UpdatedAt = r.now()
+
+	result, err := r.collection.InsertOne(ctx, webhook)
+	if err != nil {
+		return err
+	}
+	webhook.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindByID finds a webhook by ID
+func (r *WebhookRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error) {
+	var webhook models.Webhook
+
+	filter := bson.M{"_id": id}
+	err := r.collection.FindOne(ctx, filter).Decode(&webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// FindAll finds every registered webhook
+func (r *WebhookRepository) FindAll(ctx context.Context) ([]*models.Webhook, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []*models.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// FindSubscribed finds the active webhooks whose event filter includes event
+func (r *WebhookRepository) FindSubscribed(ctx context.Context, event string) ([]*models.Webhook, error) {
+	filter := bson.M{
+		"is_active": true,
+		"events":    event,
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []*models.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// Update saves changes to a webhook
+func (r *WebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	webhook.UpdatedAt = r.now()
+
+	filter := bson.M{"_id": webhook.ID}
+	update := bson.M{"$set": webhook}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// Delete removes a webhook. It returns false if no webhook had the given ID.
+func (r *WebhookRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,129 @@
+// Package webhook signs and sends booking events to endpoints registered by admins.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Booking lifecycle events
+const (
+	EventBookingCreated     = "booking.created"
+	EventBookingCancelled   = "booking.cancelled"
+	EventBookingRescheduled = "booking.rescheduled"
+	EventBookingCompleted   = "booking.completed"
+	EventBookingNoShow      = "booking.no_show"
+
+	// EventPing is sent by the admin test endpoint and ignores event filters
+	EventPing = "ping"
+)
+
+// Events lists the events a webhook can subscribe to
+var Events = []string{
+	EventBookingCreated,
+	EventBookingCancelled,
+	EventBookingRescheduled,
+	EventBookingCompleted,
+	EventBookingNoShow,
+}
+
+// ValidEvent reports whether event can be used in a webhook's event filter
+func ValidEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Request headers
+const (
+	HeaderEvent     = "X-Courtminton-Event"
+	HeaderDelivery  = "X-Courtminton-Delivery"
+	HeaderTimestamp = "X-Courtminton-Timestamp"
+	HeaderSignature = "X-Courtminton-Signature"
+)
+
+// MaxClockSkew is how old a timestamp receivers should accept, to limit replayed requests
+const MaxClockSkew = 5 * time.Minute
+
+// Sign returns the signature header value for a payload sent at timestamp (Unix seconds).
+// The signed string is "<timestamp>.<body>" so a captured payload cannot be replayed with a new timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header produced by Sign and rejects timestamps outside MaxClockSkew
+func Verify(secret string, timestamp string, signature string, body []byte, now time.Time) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := now.Sub(time.Unix(ts, 0))
+	if age > MaxClockSkew || age < -MaxClockSkew {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
+
+// Result describes one delivery attempt
+type Result struct {
+	StatusCode int
+	Duration   time.Duration
+}
+
+// Sender posts signed payloads to webhook endpoints
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a sender whose requests time out after timeout
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{client: &http.Client{Timeout: timeout}}
+}
+
+// Send posts body to url. Any response other than 2xx is returned as an error
+// together with the status code so it can be recorded in the delivery log.
+func (s *Sender) Send(ctx context.Context, url, secret, event, deliveryID string, body []byte) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Courtminton-Webhook/1.0")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	result := Result{Duration: time.Since(start)}
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return result, fmt.Errorf("endpoint responded %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef"
+
+var testBody = []byte(`{"event":"booking.created","booking":{"courtNumber":1}}`)
+
+func TestSign(t *testing.T) {
+	timestamp := int64(1893456000)
+
+	// the signature is an HMAC-SHA256 over "<timestamp>.<body>"
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte("1893456000." + string(testBody)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := Sign(testSecret, timestamp, testBody); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if Sign(testSecret, timestamp+1, testBody) == want {
+		t.Error("signature does not cover the timestamp")
+	}
+	if Sign("another-secret-value", timestamp, testBody) == want {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+	timestamp := now.Unix()
+	signature := Sign(testSecret, timestamp, testBody)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		want      bool
+	}{
+		{
+			name:      "valid",
+			secret:    testSecret,
+			timestamp: strconv.FormatInt(timestamp, 10),
+			signature: signature,
+			body:      testBody,
+			want:      true,
+		},
+		{
+			name:      "within clock skew",
+			secret:    testSecret,
+			timestamp: strconv.FormatInt(timestamp-60, 10),
+			signature: Sign(testSecret, timestamp-60, testBody),
+			body:      testBody,
+			want:      true,
+		},
+		{
+			name:      "stale timestamp",
+			secret:    testSecret,
+			timestamp: strconv.FormatInt(timestamp-int64(MaxClockSkew/time.Second)-1, 10),
+			signature: Sign(testSecret, timestamp-int64(MaxClockSkew/time.Second)-1, testBody),
+			body:      testBody,
+			want:      false,
+		},
+		{
+			name:      "timestamp in the future",
+			secret:    testSecret,
+			timestamp: strconv.FormatInt(timestamp+int64(MaxClockSkew/time.Second)+1, 10),
+			signature: Sign(testSecret, timestamp+int64(MaxClockSkew/time.Second)+1, testBody),
+			body:      testBody,
+			want:      false,
+		},
+		{
+			name:      "tampered body",
+			secret:    testSecret,
+			timestamp: strconv.FormatInt(timestamp, 10),
+			signature: signature,
+			body:      []byte(strings.Replace(string(testBody), `"courtNumber":1`, `"courtNumber":2`, 1)),
+			want:      false,
+		},
+		{
+			// a captured payload replayed with a fresh timestamp no longer matches
+			name:      "replayed with a new timestamp",
+			secret:    testSecret,
+			timestamp: strconv.FormatInt(timestamp+30, 10),
+			signature: signature,
+			body:      testBody,
+			want:      false,
+		},
+		{
+			name:      "wrong secret",
+			secret:    "another-secret-value",
+			timestamp: strconv.FormatInt(timestamp, 10),
+			signature: signature,
+			body:      testBody,
+			want:      false,
+		},
+		{
+			name:      "malformed timestamp",
+			secret:    testSecret,
+			timestamp: "yesterday",
+			signature: signature,
+			body:      testBody,
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.timestamp, tt.signature, tt.body, now); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	var received http.Header
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	result, err := NewSender(5*time.Second).Send(context.Background(), server.URL, testSecret, EventBookingCreated, "delivery-1", testBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StatusCode != http.StatusNoContent {
+		t.Errorf("got status %d, want %d", result.StatusCode, http.StatusNoContent)
+	}
+
+	if got := received.Get(HeaderEvent); got != EventBookingCreated {
+		t.Errorf("got event header %q, want %q", got, EventBookingCreated)
+	}
+	if got := received.Get(HeaderDelivery); got != "delivery-1" {
+		t.Errorf("got delivery header %q, want %q", got, "delivery-1")
+	}
+	if string(receivedBody) != string(testBody) {
+		t.Errorf("got body %s, want %s", receivedBody, testBody)
+	}
+	// the receiver can check the request with Verify
+	if !Verify(testSecret, received.Get(HeaderTimestamp), received.Get(HeaderSignature), receivedBody, time.Now()) {
+		t.Error("signature sent with the request does not verify")
+	}
+}
+
+func TestSendErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	result, err := NewSender(5*time.Second).Send(context.Background(), server.URL, testSecret, EventBookingCreated, "delivery-1", testBody)
+	if err == nil {
+		t.Fatal("expected an error for a 5xx response")
+	}
+	if result.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d", result.StatusCode, http.StatusServiceUnavailable)
+	}
+	if !strings.Contains(err.Error(), "upstream unavailable") {
+		t.Errorf("error %q does not include the response body", err)
+	}
+}
@@ -8,30 +8,39 @@ import (
 	"courtopia-reserve/backend/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Claims represents JWT claims
 type Claims struct {
-	StudentID string `json:"studentId"`
-	Role      string `json:"role"`
-	Email 	  string `json:"email,omitempty"`
+	StudentID string   `json:"studentId"`
+	Role      string   `json:"role"`
+	Email     string   `json:"email,omitempty"`
+	SessionID string   `json:"sid"`              // session ที่ออก token นี้ ถูกยกเลิกได้ตอน logout
+	Venues    []string `json:"venues,omitempty"` // สนามที่ผู้ใช้ดูแล ว่าง = ทุกสนาม
 	jwt.RegisteredClaims
 }
 
-// GenerateToken creates a new JWT token for a user
-func GenerateToken(user *models.User, secret string, expiryHours int) (string, error) {
-	expirationTime := time.Now().Add(time.Duration(expiryHours) * time.Hour)
+// GenerateToken creates a new short-lived access token for a user's session.
+// Each token gets a unique ID (jti) so it can be revoked on its own.
+func GenerateToken(user *models.User, secret string, sessionID string, ttl time.Duration) (string, error) {
+	expirationTime := time.Now().Add(ttl)
 
 	claims := &Claims{
 		StudentID: user.StudentID,
 		Role:      user.Role,
-		Email:	  user.Email,
+		Email:     user.Email,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        primitive.NewObjectID().Hex(),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   user.ID.Hex(),
 		},
 	}
+	for _, venueID := range user.VenueIDs {
+		claims.Venues = append(claims.Venues, venueID.Hex())
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(secret))
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GenerateOpaqueToken creates a random token of the form "<id>.<secret>" and the hash to store.
+// The id lets the server find the stored record without trusting the secret part,
+// and only the hash of the whole token is kept in the database.
+func GenerateOpaqueToken(id string) (token string, hash string, err error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", err
+	}
+
+	token = id + "." + hex.EncodeToString(random)
+	return token, HashToken(token), nil
+}
+
+// OpaqueTokenID returns the id part of a token created by GenerateOpaqueToken
+func OpaqueTokenID(token string) (string, bool) {
+	id, secret, ok := strings.Cut(token, ".")
+	if !ok || id == "" || secret == "" {
+		return "", false
+	}
+	return id, true
+}
+
+// HashToken returns the SHA-256 hash of a token as hex.
+// Tokens are long and random, so a fast hash is enough (unlike passwords).
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SignActionToken creates a token for a single-use action such as verifying an email address.
+// The token is "<id>.<expiry>.<signature>", signed for one purpose so it cannot be reused for another.
+func SignActionToken(secret string, purpose string, id string, expiresAt time.Time) string {
+	exp := strconv.FormatInt(expiresAt.Unix(), 10)
+	return id + "." + exp + "." + actionSignature(secret, purpose, id, exp)
+}
+
+// ParseActionToken checks the signature and expiry of a token created by SignActionToken
+// and returns its id. Whether the token was already used is tracked by the caller.
+func ParseActionToken(secret string, purpose string, token string, now time.Time) (string, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", false
+	}
+	id, exp, signature := parts[0], parts[1], parts[2]
+
+	if !hmac.Equal([]byte(signature), []byte(actionSignature(secret, purpose, id, exp))) {
+		return "", false
+	}
+
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || !now.Before(time.Unix(expUnix, 0)) {
+		return "", false
+	}
+
+	return id, true
+}
+
+func actionSignature(secret, purpose, id, exp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s:%s:%s", purpose, id, exp)
+	return hex.EncodeToString(mac.Sum(nil))
+}